@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/gzuidhof/tygo/config"
 	"github.com/gzuidhof/tygo/tygo"
@@ -19,14 +20,47 @@ func Execute() {
 
 	rootCmd.PersistentFlags().
 		String("config", "tygo.yaml", "config file to load (default is tygo.yaml in the current folder)")
+	rootCmd.PersistentFlags().
+		StringSlice("overlay", nil, "config files to merge on top of the config file, e.g. for a specific environment")
 	rootCmd.Version = FullVersion()
 	rootCmd.PersistentFlags().BoolP("debug", "D", false, "Debug mode (prints debug messages)")
 
-	rootCmd.AddCommand(&cobra.Command{
+	generateCmd := &cobra.Command{
 		Use:   "generate",
 		Short: "Generate and write to disk",
 		Run:   generate,
+	}
+	generateCmd.Flags().Bool("strict", false, "Fail if any Go type has to be replaced by the fallback type")
+	generateCmd.Flags().Bool("no-cache", false, "Generate every package, even if the config enables the cache")
+	generateCmd.Flags().IntP("jobs", "j", 0, "Number of packages to generate at the same time (default is the number of CPUs)")
+	rootCmd.AddCommand(generateCmd)
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a starter config for the Go module in the current folder",
+		Run:   initConfig,
+	}
+	initCmd.Flags().String("output-dir", "types", "folder the generated Typescript files are written to")
+	initCmd.Flags().Bool("force", false, "overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the config file",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file for unknown keys and invalid values",
+		Run:   validateConfig,
+	})
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the config file",
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Stdout.Write(config.Schema)
+		},
 	})
+	rootCmd.AddCommand(configCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -39,11 +73,137 @@ func generate(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	tygoConfig := config.ReadFromFilepath(cfgFilepath)
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		log.Fatal(err)
+	}
+	jobs, err := cmd.Flags().GetInt("jobs")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	overlays, err := cmd.Flags().GetStringSlice("overlay")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tygoConfig := config.ReadFromFilepath(cfgFilepath, overlays...)
+	tygoConfig.Strict = tygoConfig.Strict || strict
+	if noCache {
+		tygoConfig.Cache = nil
+	}
 	t := tygo.New(&tygoConfig)
+	t.SetJobs(jobs)
 
 	err = t.Generate()
+	printDiagnostics(t.Diagnostics(), tygoConfig.Strict)
 	if err != nil {
 		log.Fatalf("Tygo failed: %v", err)
 	}
+	printSummary(t.Summary())
+}
+
+func printSummary(summary tygo.WriteSummary) {
+	fmt.Printf("Tygo wrote %d file(s), %d unchanged", len(summary.Written), len(summary.Unchanged))
+	if len(summary.Removed) > 0 {
+		fmt.Printf(", removed %d stale file(s):\n", len(summary.Removed))
+		for _, path := range summary.Removed {
+			fmt.Printf("  %s\n", path)
+		}
+		return
+	}
+	fmt.Println()
+}
+
+func validateConfig(cmd *cobra.Command, args []string) {
+	cfgFilepath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	overlays, err := cmd.Flags().GetStringSlice("overlay")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conf, err := config.Load(cfgFilepath, overlays...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = conf.Validate()
+	if err != nil {
+		log.Fatalf("Invalid config file %s:\n%v", cfgFilepath, err)
+	}
+	fmt.Printf("%s is valid\n", cfgFilepath)
+}
+
+func initConfig(cmd *cobra.Command, args []string) {
+	cfgFilepath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputDir, err := cmd.Flags().GetString("output-dir")
+	if err != nil {
+		log.Fatal(err)
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := os.Stat(cfgFilepath); err == nil && !force {
+		log.Fatalf("Config file %s already exists, use --force to overwrite it", cfgFilepath)
+	}
+
+	contents, err := config.Scaffold(".", outputDir)
+	if err != nil {
+		log.Fatalf("Tygo init failed: %v", err)
+	}
+
+	err = os.WriteFile(cfgFilepath, []byte(contents), 0o664)
+	if err != nil {
+		log.Fatalf("Could not write config file %s: %v", cfgFilepath, err)
+	}
+	fmt.Printf("Wrote %s\n", cfgFilepath)
+}
+
+// printDiagnostics prints the diagnostics grouped by kind. In strict mode, the uses of the
+// fallback type are left out, as they are reported by the error instead.
+func printDiagnostics(diagnostics []tygo.Diagnostic, strict bool) {
+	headers := map[tygo.DiagnosticKind]string{
+		tygo.FallbackDiagnostic:         "Tygo used the fallback type %d time(s):\n",
+		tygo.ReservedNameDiagnostic:     "Tygo found %d name(s) that are reserved or shadow a global in Typescript:\n",
+		tygo.OmittedReferenceDiagnostic: "Tygo found %d reference(s) to declarations that are left out of the output:\n",
+	}
+
+	wd, _ := os.Getwd()
+	for _, kind := range []tygo.DiagnosticKind{
+		tygo.FallbackDiagnostic, tygo.ReservedNameDiagnostic, tygo.OmittedReferenceDiagnostic,
+	} {
+		if strict && kind == tygo.FallbackDiagnostic {
+			continue
+		}
+		var ofKind []tygo.Diagnostic
+		for _, d := range diagnostics {
+			if d.Kind == kind {
+				ofKind = append(ofKind, d)
+			}
+		}
+		if len(ofKind) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, headers[kind], len(ofKind))
+		for _, d := range ofKind {
+			if rel, err := filepath.Rel(wd, d.Pos.Filename); err == nil && wd != "" {
+				d.Pos.Filename = rel
+			}
+			fmt.Fprintf(os.Stderr, "  %s\n", d)
+		}
+	}
 }
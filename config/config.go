@@ -1,23 +1,116 @@
 package config
 
 import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"path/filepath"
 
 	"github.com/gzuidhof/tygo/tygo"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-func ReadFromFilepath(cfgFilepath string) tygo.Config {
-	b, err := ioutil.ReadFile(cfgFilepath)
+// Schema is the JSON Schema of tygo.yaml.
+//
+//go:embed tygo.schema.json
+var Schema []byte
+
+func ReadFromFilepath(cfgFilepath string, overlays ...string) tygo.Config {
+	conf, err := Load(cfgFilepath, overlays...)
 	if err != nil {
-		log.Fatalf("Could not read config file from %s: %v", cfgFilepath, err)
+		log.Fatal(err)
 	}
-	conf := tygo.Config{}
-	err = yaml.Unmarshal(b, &conf)
+
+	err = conf.Validate()
 	if err != nil {
-		log.Fatalf("Could not parse config file from: %v", err)
+		log.Fatalf("Invalid config file %s:\n%v", cfgFilepath, err)
 	}
 
 	return conf
 }
+
+// Load reads the config file at cfgFilepath together with the files it includes. Unknown
+// keys are an error, so that typos in option names don't go unnoticed.
+//
+// Overlays are config files that are merged on top of it in order, for instance to change
+// output paths per environment.
+func Load(cfgFilepath string, overlays ...string) (tygo.Config, error) {
+	conf, err := loadFile(cfgFilepath, nil)
+	if err != nil {
+		return conf, err
+	}
+
+	for _, overlay := range overlays {
+		overlayConf, err := loadFile(overlay, nil)
+		if err != nil {
+			return conf, err
+		}
+		conf = conf.Merge(overlayConf)
+	}
+
+	err = interpolatePaths(&conf)
+	if err != nil {
+		return conf, fmt.Errorf("invalid config file %s: %w", cfgFilepath, err)
+	}
+	return conf, nil
+}
+
+// loadFile reads a single config file and merges in the files it includes. The stack holds
+// the files that are currently being included, to detect include cycles.
+func loadFile(cfgFilepath string, stack []string) (tygo.Config, error) {
+	absPath, err := filepath.Abs(cfgFilepath)
+	if err != nil {
+		return tygo.Config{}, err
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return tygo.Config{}, fmt.Errorf("config file %s includes itself", cfgFilepath)
+		}
+	}
+
+	b, err := ioutil.ReadFile(cfgFilepath)
+	if err != nil {
+		return tygo.Config{}, fmt.Errorf("could not read config file from %s: %w", cfgFilepath, err)
+	}
+
+	conf, err := parse(b)
+	if err != nil {
+		return conf, fmt.Errorf("could not parse config file %s: %w", cfgFilepath, err)
+	}
+
+	included := tygo.Config{}
+	for _, include := range conf.Include {
+		include, err = expandEnv(include)
+		if err != nil {
+			return conf, fmt.Errorf("invalid include in config file %s: %w", cfgFilepath, err)
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(cfgFilepath), include)
+		}
+
+		includedConf, err := loadFile(include, append(stack, absPath))
+		if err != nil {
+			return conf, err
+		}
+		included = included.Merge(includedConf)
+	}
+	conf.Include = nil
+
+	return included.Merge(conf), nil
+}
+
+func parse(b []byte) (tygo.Config, error) {
+	conf := tygo.Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err := dec.Decode(&conf)
+	if errors.Is(err, io.EOF) { // Empty file
+		return conf, nil
+	}
+	return conf, err
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gzuidhof/tygo/tygo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	_, err := parse([]byte(`
+packages:
+  - path: "github.com/my/package"
+    output-path: "types.ts"
+    enum_stlye: "enum"
+    types:
+      Book:
+        renamed: "Novel"
+index:
+  paht: "types"
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 4: field output-path not found")
+	assert.Contains(t, err.Error(), "line 5: field enum_stlye not found")
+	assert.Contains(t, err.Error(), "line 8: field renamed not found in type tygo.TypeConfig")
+	assert.Contains(t, err.Error(), "line 10: field paht not found in type tygo.IndexConfig")
+	assert.Equal(t, 1, strings.Count(err.Error(), "output-path"))
+}
+
+func TestValidateReportsAllPackages(t *testing.T) {
+	t.Parallel()
+
+	conf, err := parse([]byte(`
+packages:
+  - path: "github.com/my/a"
+    flavor: "toml"
+  - path: "github.com/my/b"
+    enum_style: "enums"
+    optional_type: "nil"
+`))
+	require.NoError(t, err)
+
+	err = conf.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported flavor: toml")
+	assert.Contains(t, err.Error(), "unsupported enum_style: enums")
+	assert.Contains(t, err.Error(), "unsupported optional: nil")
+}
+
+func yamlKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaKeys(properties map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The JSON Schema has to be kept in sync with the config structs by hand.
+func TestSchemaMatchesConfig(t *testing.T) {
+	t.Parallel()
+
+	var schema struct {
+		Properties  map[string]json.RawMessage
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage
+		}
+	}
+	require.NoError(t, json.Unmarshal(Schema, &schema))
+
+	assert.Equal(t, yamlKeys(reflect.TypeOf(tygo.Config{})), schemaKeys(schema.Properties))
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.PackageConfig{})),
+		schemaKeys(schema.Definitions["package"].Properties),
+	)
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.TypeNaming{})),
+		schemaKeys(schema.Definitions["typeNaming"].Properties),
+	)
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.TypeConfig{})),
+		schemaKeys(schema.Definitions["type"].Properties),
+	)
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.FieldOverride{})),
+		schemaKeys(schema.Definitions["fieldOverride"].Properties),
+	)
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.IndexConfig{})),
+		schemaKeys(schema.Definitions["index"].Properties),
+	)
+	assert.Equal(t,
+		yamlKeys(reflect.TypeOf(tygo.CacheConfig{})),
+		schemaKeys(schema.Definitions["cache"].Properties),
+	)
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o664))
+}
+
+func TestLoadIncludesDefaultsAndOverlays(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TYGO_TEST_OUT", "web/types")
+
+	writeFile(t, filepath.Join(dir, "platform", "mappings.yaml"), `
+type_mappings:
+  time.Time: "string"
+  uuid.UUID: "string /* uuid */"
+package_defaults:
+  fallback_type: "unknown"
+  enum_style: "union"
+`)
+	writeFile(t, filepath.Join(dir, "tygo.yaml"), `
+include:
+  - "platform/mappings.yaml"
+type_mappings:
+  time.Time: "string /* RFC3339 */"
+package_defaults:
+  enum_style: "enum"
+cache:
+  dir: "${TYGO_TEST_UNSET:-.tygo-cache}"
+packages:
+  - path: "github.com/my/a"
+    output_path: "${TYGO_TEST_OUT}/a.ts"
+  - path: "github.com/my/b"
+    output_path: "${TYGO_TEST_UNSET:-types}/b.ts"
+    fallback_type: "any"
+`)
+	writeFile(t, filepath.Join(dir, "tygo.prod.yaml"), `
+packages:
+  - path: "github.com/my/b"
+    output_path: "dist/b.ts"
+`)
+
+	conf, err := Load(filepath.Join(dir, "tygo.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, conf.Validate())
+	assert.Equal(t, ".tygo-cache", conf.Cache.Dir)
+
+	a := conf.PackageConfig("github.com/my/a")
+	assert.Equal(t, "web/types/a.ts", a.OutputPath)
+	assert.Equal(t, "unknown", a.FallbackType)
+	assert.Equal(t, "enum", a.EnumStyle)
+	assert.Equal(t, map[string]string{
+		"time.Time": "string /* RFC3339 */",
+		"uuid.UUID": "string /* uuid */",
+	}, a.TypeMappings)
+
+	b := conf.PackageConfig("github.com/my/b")
+	assert.Equal(t, "types/b.ts", b.OutputPath)
+	assert.Equal(t, "any", b.FallbackType)
+
+	conf, err = Load(filepath.Join(dir, "tygo.yaml"), filepath.Join(dir, "tygo.prod.yaml"))
+	require.NoError(t, err)
+	require.Len(t, conf.Packages, 2)
+	b = conf.PackageConfig("github.com/my/b")
+	assert.Equal(t, "dist/b.ts", b.OutputPath)
+	assert.Equal(t, "any", b.FallbackType)
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "cycle.yaml"), `
+include: ["cycle.yaml"]
+`)
+	_, err := Load(filepath.Join(dir, "cycle.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "includes itself")
+
+	writeFile(t, filepath.Join(dir, "env.yaml"), `
+packages:
+  - path: "github.com/my/a"
+    output_path: "${TYGO_TEST_DEFINITELY_UNSET}/a.ts"
+`)
+	_, err = Load(filepath.Join(dir, "env.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environment variable TYGO_TEST_DEFINITELY_UNSET is not set")
+}
+
+func TestLoadTurnsOffDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), `
+strict: true
+packages:
+  - path: "github.com/my/b"
+    output_path: "types/b"
+    strict: true
+`)
+	writeFile(t, filepath.Join(dir, "tygo.yaml"), `
+include:
+  - "base.yaml"
+strict: false
+package_defaults:
+  strict: true
+packages:
+  - path: "github.com/my/a"
+    strict: false
+  - path: "github.com/my/b"
+    strict: false
+`)
+
+	conf, err := Load(filepath.Join(dir, "tygo.yaml"))
+	require.NoError(t, err)
+	assert.False(t, conf.Strict)
+	assert.False(t, conf.PackageConfig("github.com/my/a").Strict)
+
+	b := conf.PackageConfig("github.com/my/b")
+	assert.False(t, b.Strict)
+	assert.Equal(t, "types/b", b.OutputPath)
+}
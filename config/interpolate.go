@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gzuidhof/tygo/tygo"
+)
+
+// Matches `${VAR}` and `${VAR:-default}`.
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces the environment variables in s, it is an error if a variable is not set
+// and has no default value.
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := envVarRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		groups := envVarRegexp.FindStringSubmatch(match)
+		if value, ok := os.LookupEnv(groups[1]); ok && value != "" {
+			return value
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		missing = append(missing, groups[1])
+		return match
+	})
+
+	if len(missing) > 0 {
+		return s, fmt.Errorf("environment variable %s is not set in %q", strings.Join(missing, ", "), s)
+	}
+	return expanded, nil
+}
+
+// interpolatePaths expands the environment variables in the paths of every package, of the
+// index and of the cache.
+func interpolatePaths(conf *tygo.Config) error {
+	if conf.Index != nil {
+		path, err := expandEnv(conf.Index.Path)
+		if err != nil {
+			return err
+		}
+		// The index may be shared with an included config file.
+		index := *conf.Index
+		index.Path = path
+		conf.Index = &index
+	}
+	if conf.Cache != nil {
+		dir, err := expandEnv(conf.Cache.Dir)
+		if err != nil {
+			return err
+		}
+		cache := *conf.Cache
+		cache.Dir = dir
+		conf.Cache = &cache
+	}
+
+	pcs := conf.Packages
+	if conf.PackageDefaults != nil {
+		pcs = append([]*tygo.PackageConfig{conf.PackageDefaults}, pcs...)
+	}
+
+	var err error
+	for _, pc := range pcs {
+		if pc == nil { // Reported by Validate
+			continue
+		}
+		pc.Path, err = expandEnv(pc.Path)
+		if err != nil {
+			return err
+		}
+		pc.OutputPath, err = expandEnv(pc.OutputPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
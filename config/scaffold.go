@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Type mappings that are written into every scaffolded config.
+var scaffoldDefaultMappings = []string{"time.Time", "uuid.UUID"}
+
+// Commonly needed type mappings for types from outside the module, keyed by how they
+// are referenced in Go code.
+var scaffoldKnownMappings = map[string]string{
+	"time.Time":       "string /* RFC3339 */",
+	"time.Duration":   "number /* int, ns */",
+	"uuid.UUID":       "string /* uuid */",
+	"uuid.NullUUID":   "null | string /* uuid */",
+	"json.RawMessage": "unknown",
+	"null.String":     "null | string",
+	"null.Bool":       "null | boolean",
+	"null.Int":        "null | number",
+	"null.Float":      "null | number",
+	"null.Time":       "null | string /* RFC3339 */",
+	"decimal.Decimal": "string /* decimal */",
+}
+
+// Scaffold inspects the Go module in dir and returns the contents of a starter tygo.yaml.
+//
+// Every package containing an exported struct with `json` tags is added, with its output
+// written to a folder mirroring the package directory within outputDir. If any package
+// fails to load, the errors are returned instead of a config that would miss it.
+func Scaffold(dir string, outputDir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:  absDir,
+	}, "./...")
+	if err != nil {
+		return "", err
+	}
+	var loadErrs []string
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			loadErrs = append(loadErrs, e.Error())
+		}
+	}
+	if len(loadErrs) > 0 {
+		return "", fmt.Errorf("failed to load packages in %s:\n%s", absDir, strings.Join(loadErrs, "\n"))
+	}
+
+	mappings := make(map[string]bool)
+	for _, m := range scaffoldDefaultMappings {
+		mappings[m] = true
+	}
+
+	packagesSection := new(strings.Builder)
+	packagesFound := 0
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 || !hasJSONStruct(pkg.Syntax) {
+			continue
+		}
+		collectSelectors(pkg.Syntax, mappings)
+
+		packagesFound++
+
+		rel, err := filepath.Rel(absDir, filepath.Dir(pkg.GoFiles[0]))
+		if err != nil {
+			return "", err
+		}
+		outputPath := filepath.ToSlash(filepath.Join(outputDir, rel, "index.ts"))
+
+		fmt.Fprintf(packagesSection, "  - path: %q\n", pkg.PkgPath)
+		fmt.Fprintf(packagesSection, "    output_path: %q\n", outputPath)
+	}
+
+	if packagesFound == 0 {
+		return "", fmt.Errorf("no packages with exported structs with json tags found in %s", absDir)
+	}
+
+	names := make([]string, 0, len(mappings))
+	for name := range mappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := new(strings.Builder)
+	s.WriteString("# Generated by `tygo init`, see https://github.com/gzuidhof/tygo#config for all options.\n")
+
+	s.WriteString("# Translations for types from outside this module, used by all packages.\n")
+	s.WriteString("type_mappings:\n")
+	for _, name := range names {
+		fmt.Fprintf(s, "  %s: %q\n", name, scaffoldKnownMappings[name])
+	}
+
+	s.WriteString("\n# Options that apply to every package.\n")
+	s.WriteString("package_defaults:\n")
+	s.WriteString("  fallback_type: unknown\n")
+
+	s.WriteString("\npackages:\n")
+	s.WriteString(packagesSection.String())
+
+	return s.String(), nil
+}
+
+// hasJSONStruct returns true if any of the files declares an exported struct with a field
+// that has a `json` tag.
+func hasJSONStruct(files []*ast.File) bool {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, f := range st.Fields.List {
+					if f.Tag == nil {
+						continue
+					}
+					tag := reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
+					if _, ok := tag.Lookup("json"); ok {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// collectSelectors marks every known mapping that is referenced in a type declaration.
+func collectSelectors(files []*ast.File, found map[string]bool) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				inspectSelectors(gd, found)
+			}
+		}
+	}
+}
+
+func inspectSelectors(decl *ast.GenDecl, found map[string]bool) {
+	ast.Inspect(decl, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		name := x.Name + "." + sel.Sel.Name
+		if _, known := scaffoldKnownMappings[name]; known {
+			found[name] = true
+		}
+		return true
+	})
+}
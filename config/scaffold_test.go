@@ -0,0 +1,45 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffold(t *testing.T) {
+	t.Parallel()
+
+	contents, err := Scaffold("../examples", "web/types")
+	require.NoError(t, err)
+
+	conf, err := parse([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, conf.Validate())
+
+	bookstore := conf.PackageConfig("github.com/gzuidhof/tygo/examples/bookstore")
+	assert.Equal(t, "web/types/bookstore/index.ts", bookstore.OutputPath)
+	assert.Equal(t, "unknown", bookstore.FallbackType)
+
+	// Packages without exported structs with json tags are left out.
+	assert.NotContains(t, conf.PackageNames(), "github.com/gzuidhof/tygo/examples/rune")
+
+	assert.Contains(t, conf.TypeMappings, "time.Time")
+	assert.Contains(t, conf.TypeMappings, "uuid.UUID")
+	assert.Contains(t, conf.TypeMappings, "null.String")
+}
+
+func TestScaffoldReportsLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/broken\n\ngo 1.18\n")
+	writeFile(t, filepath.Join(dir, "api", "api.go"), "package api\n\ntype User struct {\n\tName string `json:\"name\"`\n}\n")
+	writeFile(t, filepath.Join(dir, "store", "store.go"), "package store\n\ntype Book struct {\n")
+
+	_, err := Scaffold(dir, "web/types")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load packages")
+	assert.Contains(t, err.Error(), "store.go")
+}
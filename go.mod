@@ -4,7 +4,6 @@ go 1.18
 
 require (
 	github.com/spf13/cobra v1.3.0
-	gopkg.in/yaml.v2 v2.4.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -0,0 +1,261 @@
+package tygo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// bundle is a set of packages that are generated into a single output file.
+type bundle struct {
+	style string
+	// The packages in the bundle keyed by their path, and their Go package names.
+	members  map[string]*PackageGenerator
+	pkgNames map[string]string
+}
+
+// bundledTypeName returns the name of a type of another package in the same bundle, like
+// `bookapp.Book` in a namespace bundle or `BookappBook` in a prefix bundle. It returns false
+// if the type is not part of the bundle.
+func (g *PackageGenerator) bundledTypeName(t *ast.SelectorExpr) (string, bool) {
+	if g.bundle == nil {
+		return "", false
+	}
+	x, ok := t.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	member, ok := g.bundle.members[g.imports[x.Name]]
+	if !ok || member == g || !member.emitsType(t.Sel.Name) {
+		return "", false
+	}
+
+	name := member.tsTypeName(t.Sel.Name)
+	if member.namespace != "" {
+		name = member.namespace + "." + name
+	}
+	return name, true
+}
+
+// emitsType returns true if the type with the given name is declared in the output.
+func (g *PackageGenerator) emitsType(name string) bool {
+	_, declared := g.typeNames[name]
+	return declared && token.IsExported(name) && !g.conf.IsTypeIgnored(name) && g.isReachable(name)
+}
+
+// generateBundle generates the packages that share an output path into one file. It
+// returns the error of every package, in the same order. The file is only written if all
+// of them succeed.
+func (g *Tygo) generateBundle(
+	res *generateResult,
+	outPath string,
+	pkgs []*packages.Package,
+	confs []*PackageConfig,
+	reachable map[string]map[string]bool,
+) []error {
+	errs := make([]error, len(pkgs))
+	failAll := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	paths := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		paths[i] = pkg.PkgPath
+	}
+	style := confs[0].Bundle
+	for _, conf := range confs {
+		if conf.Bundle == "" {
+			return failAll(fmt.Errorf(
+				"output path %s is shared by packages %s, set bundle to combine them into one file",
+				outPath, strings.Join(paths, ", "),
+			))
+		}
+		if conf.Bundle != style {
+			return failAll(fmt.Errorf(
+				"packages %s share output path %s but use different bundle styles",
+				strings.Join(paths, ", "), outPath,
+			))
+		}
+		if conf.OutputKind != confs[0].OutputKind {
+			return failAll(fmt.Errorf(
+				"packages %s share output path %s but use different output kinds",
+				strings.Join(paths, ", "), outPath,
+			))
+		}
+	}
+
+	b := &bundle{
+		style:    style,
+		members:  make(map[string]*PackageGenerator),
+		pkgNames: make(map[string]string),
+	}
+	gens := make([]*PackageGenerator, len(pkgs))
+	names := make(map[string]string)
+	for i, pkg := range pkgs {
+		name := confs[i].BundleName
+		if name == "" {
+			name = pkg.Name
+		}
+		if style == "prefix" {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		if other, ok := names[name]; ok {
+			return failAll(fmt.Errorf(
+				"packages %s and %s are both named %s in the bundle %s, set bundle_name to tell them apart",
+				other, pkg.PkgPath, name, outPath,
+			))
+		}
+		names[name] = pkg.PkgPath
+
+		gens[i] = &PackageGenerator{
+			conf:           confs[i],
+			GoFiles:        pkg.GoFiles,
+			pkg:            pkg,
+			generatedEnums: make(map[string]bool),
+			reachable:      reachable[pkg.PkgPath],
+			fset:           g.fset,
+			bundle:         b,
+		}
+		if style == "namespace" {
+			gens[i].namespace = name
+		} else {
+			gens[i].namePrefix = name
+		}
+		b.members[pkg.PkgPath] = gens[i]
+		b.pkgNames[pkg.PkgPath] = pkg.Name
+		res.generators = append(res.generators, gens[i])
+	}
+
+	// All names have to be known before any package refers to another one.
+	for _, gen := range gens {
+		files, _ := gen.sourceFiles()
+		gen.preProcessTypes(files)
+	}
+
+	s := new(strings.Builder)
+	gens[0].writeFileCodegenHeader(s)
+	frontmatters := make(map[string]bool)
+	for _, gen := range gens {
+		if !frontmatters[gen.conf.Frontmatter] {
+			gen.writeFileFrontmatter(s)
+			frontmatters[gen.conf.Frontmatter] = true
+		}
+	}
+
+	failed := false
+	for i, gen := range gens {
+		body := new(strings.Builder)
+		errs[i] = gen.generateBody(body)
+		res.diagnostics = append(res.diagnostics, gen.Diagnostics()...)
+		if errs[i] != nil {
+			failed = true
+			continue
+		}
+
+		if gen.namespace == "" {
+			s.WriteString(body.String())
+			continue
+		}
+		s.WriteString("\nexport ")
+		if gen.isDeclarationFile() {
+			s.WriteString("declare ")
+		}
+		s.WriteString("namespace ")
+		s.WriteString(gen.namespace)
+		s.WriteString(" {")
+		s.WriteString(indentCode(body.String(), gen.conf.Indent))
+		s.WriteString("}\n")
+	}
+
+	if failed {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = fmt.Errorf("not written, another package in the bundle %s failed", filepath.Base(outPath))
+			}
+		}
+		return errs
+	}
+
+	if err := res.write(outPath, s.String()); err != nil {
+		return failAll(err)
+	}
+	res.outputs = append(res.outputs, newOutput(outPath, s.String(), gens...))
+	return errs
+}
+
+// indentCode indents every non-empty line of the Typescript code, except for lines that
+// continue a multi-line template literal, as that would change its value.
+func indentCode(code string, indent string) string {
+	const (
+		inCode = iota
+		inLineComment
+		inBlockComment
+		inString
+		inTemplate
+	)
+
+	s := new(strings.Builder)
+	state := inCode
+	var quote byte
+	lineStart := true
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if lineStart {
+			lineStart = false
+			if state != inTemplate && c != '\n' {
+				s.WriteString(indent)
+			}
+		}
+		s.WriteByte(c)
+
+		switch state {
+		case inCode:
+			switch {
+			case c == '/' && i+1 < len(code) && code[i+1] == '/':
+				state = inLineComment
+			case c == '/' && i+1 < len(code) && code[i+1] == '*':
+				s.WriteByte('*')
+				i++
+				state = inBlockComment
+			case c == '\'' || c == '"':
+				state, quote = inString, c
+			case c == '`':
+				state = inTemplate
+			}
+		case inLineComment:
+			if c == '\n' {
+				state = inCode
+			}
+		case inBlockComment:
+			if c == '*' && i+1 < len(code) && code[i+1] == '/' {
+				s.WriteByte('/')
+				i++
+				state = inCode
+			}
+		case inString, inTemplate:
+			if c == '\\' && i+1 < len(code) {
+				s.WriteByte(code[i+1])
+				i++
+				if code[i] == '\n' {
+					lineStart = true
+				}
+				continue
+			}
+			if (state == inString && (c == quote || c == '\n')) || (state == inTemplate && c == '`') {
+				state = inCode
+			}
+		}
+
+		if c == '\n' {
+			lineStart = true
+		}
+	}
+	return s.String()
+}
@@ -0,0 +1,297 @@
+package tygo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const modulePath = "github.com/gzuidhof/tygo"
+
+// generateCache holds the results of the output paths whose packages have not changed since
+// they were last generated. A nil cache has no results.
+type generateCache struct {
+	dir string
+	// The keys of the output paths and the number of packages they were computed for.
+	keys  map[string]string
+	sizes map[string]int
+	hits  map[string]*generateResult
+}
+
+// cacheEntry records what was generated for the packages of an output path.
+type cacheEntry struct {
+	Key         string
+	Files       []cachedFile
+	Outputs     []cachedOutput
+	Diagnostics []Diagnostic
+}
+
+type cachedFile struct {
+	Path string
+	Hash string
+}
+
+type cachedOutput struct {
+	Path      string
+	PkgPaths  []string
+	IndexName string
+}
+
+// openCache looks up the output paths of the packages in the cache, and loads the syntax of
+// the packages that have to be generated, updating their errors in errs. An output path is
+// taken from the cache if the Go files and config of its packages are unchanged, and the
+// files written for it are still there. If any package has roots, every output path
+// depends on all packages, as roots make types of other packages reachable.
+func (g *Tygo) openCache(pkgs []*packages.Package, confs []*PackageConfig, errs []error) (*generateCache, error) {
+	c := &generateCache{
+		dir:   g.conf.Cache.ResolvedDir(),
+		keys:  make(map[string]string),
+		sizes: make(map[string]int),
+		hits:  make(map[string]*generateResult),
+	}
+
+	inputs := make([]string, len(pkgs))
+	all := sha256.New()
+	hasRoots := false
+	for i, pkg := range pkgs {
+		if errs[i] != nil {
+			continue
+		}
+		inputs[i], errs[i] = packageInputHash(pkg, confs[i])
+		fmt.Fprintln(all, inputs[i])
+		hasRoots = hasRoots || len(confs[i].Roots) > 0
+	}
+
+	outPathOrder, outPaths := groupByOutputPath(pkgs, confs, errs)
+	// Without knowing which build of tygo wrote the cache, it can't be trusted.
+	version := generatorVersion()
+	for _, outPath := range outPathOrder {
+		if version == "" {
+			break
+		}
+		h := sha256.New()
+		fmt.Fprintln(h, version)
+		if hasRoots {
+			fmt.Fprintf(h, "%x\n", all.Sum(nil))
+		}
+		for _, i := range outPaths[outPath] {
+			fmt.Fprintln(h, inputs[i])
+		}
+		c.keys[outPath] = hex.EncodeToString(h.Sum(nil))
+		c.sizes[outPath] = len(outPaths[outPath])
+
+		if res, ok := c.load(outPath); ok {
+			c.hits[outPath] = res
+		}
+	}
+
+	if hasRoots && len(c.hits) < len(outPathOrder) {
+		// The reachable types of a package with roots depend on the syntax of all packages.
+		c.hits = make(map[string]*generateResult)
+	}
+	var missed []string
+	for _, outPath := range outPathOrder {
+		if _, ok := c.hits[outPath]; !ok {
+			for _, i := range outPaths[outPath] {
+				missed = append(missed, pkgs[i].ID)
+			}
+		}
+	}
+	if len(missed) == 0 {
+		return c, nil
+	}
+
+	loaded, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedSyntax | packages.NeedFiles,
+		Fset: g.fset,
+	}, missed...)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*packages.Package)
+	for _, pkg := range loaded {
+		byID[pkg.ID] = pkg
+	}
+	for i, pkg := range pkgs {
+		if errs[i] != nil || c.isHit(confs[i], pkg) {
+			continue
+		}
+		reloaded, ok := byID[pkg.ID]
+		if !ok {
+			errs[i] = fmt.Errorf("package could not be loaded again")
+			continue
+		}
+		pkgs[i] = reloaded
+		confs[i], errs[i] = g.loadedPackageConfig(reloaded)
+	}
+	return c, nil
+}
+
+// isHit returns true if the package is taken from the cache.
+func (c *generateCache) isHit(conf *PackageConfig, pkg *packages.Package) bool {
+	_, ok := c.hit(conf.ResolvedOutputPath(filepath.Dir(pkg.GoFiles[0])))
+	return ok
+}
+
+// hit returns the cached result of the output path.
+func (c *generateCache) hit(outPath string) (*generateResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	res, ok := c.hits[outPath]
+	return res, ok
+}
+
+// entryPath returns the path of the cache entry of the output path.
+func (c *generateCache) entryPath(outPath string) string {
+	hash := sha256.Sum256([]byte(absPath(outPath)))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// load returns the cached result of the output path, if its key matches and the files are
+// unchanged.
+func (c *generateCache) load(outPath string) (*generateResult, bool) {
+	b, err := os.ReadFile(c.entryPath(outPath))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil || entry.Key != c.keys[outPath] {
+		return nil, false
+	}
+
+	res := &generateResult{diagnostics: entry.Diagnostics}
+	codes := make(map[string]string)
+	for _, file := range entry.Files {
+		code, err := os.ReadFile(file.Path)
+		if err != nil || hashString(string(code)) != file.Hash {
+			return nil, false
+		}
+		codes[file.Path] = string(code)
+		res.files = append(res.files, writtenFile{path: file.Path, code: string(code)})
+	}
+	for _, out := range entry.Outputs {
+		res.outputs = append(res.outputs, output{
+			path:      out.Path,
+			pkgPaths:  out.PkgPaths,
+			indexName: out.IndexName,
+			code:      codes[out.Path],
+		})
+	}
+	return res, true
+}
+
+// save records the result of the output path, if all of its packages were generated.
+// The cache is only an optimization, so failing to write it doesn't fail generation.
+func (c *generateCache) save(outPath string, res *generateResult) {
+	if c == nil || c.keys[outPath] == "" || len(res.errs) != c.sizes[outPath] {
+		return
+	}
+	if _, ok := c.hits[outPath]; ok {
+		return
+	}
+	for _, err := range res.errs {
+		if err != nil {
+			return
+		}
+	}
+
+	entry := cacheEntry{Key: c.keys[outPath], Diagnostics: res.diagnostics}
+	for _, file := range res.files {
+		entry.Files = append(entry.Files, cachedFile{Path: file.path, Hash: hashString(file.code)})
+	}
+	for _, out := range res.outputs {
+		entry.Outputs = append(entry.Outputs, cachedOutput{Path: out.path, PkgPaths: out.pkgPaths, IndexName: out.indexName})
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.entryPath(outPath), b, 0o664)
+}
+
+// packageInputHash returns the hash of everything the output of a package depends on,
+// apart from other packages.
+func packageInputHash(pkg *packages.Package, conf *PackageConfig) (string, error) {
+	h := sha256.New()
+	confJSON, err := json.Marshal(conf)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "%s\n%s\n%s\n", pkg.ID, pkg.Name, confJSON)
+	for _, path := range pkg.GoFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		fmt.Fprintf(h, "%s\n", path)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashString(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// generatorVersion identifies the build of tygo, so that the cache of one version is not
+// used by another. Development builds are identified by the hash of the executable. It
+// returns an empty string if the build can't be identified.
+func generatorVersion() string {
+	versionOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mod := &info.Main
+			for _, dep := range info.Deps {
+				if dep.Path == modulePath {
+					mod = dep
+				}
+			}
+			if mod.Replace != nil {
+				mod = mod.Replace
+			}
+			if mod.Path == modulePath && mod.Version != "" && mod.Version != "(devel)" &&
+				!strings.HasSuffix(mod.Version, "+dirty") {
+				version = mod.Version + " " + mod.Sum
+				return
+			}
+		}
+
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return
+		}
+		version = hex.EncodeToString(h.Sum(nil))
+	})
+	return version
+}
@@ -0,0 +1,108 @@
+package tygo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newConfig := func(fallbackType string) *Config {
+		return &Config{
+			Packages: []*PackageConfig{
+				{
+					Path:       "github.com/gzuidhof/tygo/examples/simple",
+					OutputPath: filepath.Join(dir, "simple.ts"),
+				},
+				{
+					Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+					OutputPath:   filepath.Join(dir, "layout"),
+					OutputLayout: "per_file",
+					FallbackType: fallbackType,
+				},
+			},
+			Index: &IndexConfig{Path: dir},
+			Cache: &CacheConfig{Dir: filepath.Join(dir, "cache")},
+		}
+	}
+	readIndex := func() string {
+		b, err := os.ReadFile(filepath.Join(dir, "index.ts"))
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	gen := New(newConfig("any"))
+	require.NoError(t, gen.Generate())
+	assert.Len(t, gen.packageGenerators, 2)
+	diagnostics := gen.Diagnostics()
+	require.NotEmpty(t, diagnostics)
+	index := readIndex()
+
+	// Nothing changed, so both packages are taken from the cache.
+	gen = New(newConfig("any"))
+	require.NoError(t, gen.Generate())
+	assert.Empty(t, gen.packageGenerators)
+	assert.Equal(t, diagnostics, gen.Diagnostics())
+	assert.Equal(t, index, readIndex())
+
+	// A change to the config of a package only generates that package again.
+	gen = New(newConfig("unknown"))
+	require.NoError(t, gen.Generate())
+	assert.Len(t, gen.packageGenerators, 1)
+	assert.Contains(t, gen.packageGenerators, "github.com/gzuidhof/tygo/tygo/testdata/layout")
+
+	// So does a change to the files that were written for it.
+	bookPath := filepath.Join(dir, "layout", "book.ts")
+	book, err := os.ReadFile(bookPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(bookPath, []byte("// Changed by hand\n"), 0o664))
+	gen = New(newConfig("unknown"))
+	require.NoError(t, gen.Generate())
+	assert.Len(t, gen.packageGenerators, 1)
+	b, err := os.ReadFile(bookPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(book), string(b))
+}
+
+func TestGenerateCacheWithRoots(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newConfig := func(embedFallbackType string) *Config {
+		return &Config{
+			Packages: []*PackageConfig{
+				{
+					Path:       "github.com/gzuidhof/tygo/examples/bookstore",
+					OutputPath: filepath.Join(dir, "bookstore.ts"),
+					Roots:      []string{"Chapter"},
+				},
+				{
+					Path:         "github.com/gzuidhof/tygo/examples/embed",
+					OutputPath:   filepath.Join(dir, "embed.ts"),
+					FallbackType: embedFallbackType,
+				},
+			},
+			Cache: &CacheConfig{Dir: filepath.Join(dir, "cache")},
+		}
+	}
+
+	gen := New(newConfig("unknown"))
+	require.NoError(t, gen.Generate())
+	gen = New(newConfig("unknown"))
+	require.NoError(t, gen.Generate())
+	assert.Empty(t, gen.packageGenerators)
+
+	// The types of bookstore that are reachable depend on embed.
+	gen = New(newConfig("any"))
+	require.NoError(t, gen.Generate())
+	assert.Len(t, gen.packageGenerators, 2)
+	b, err := os.ReadFile(filepath.Join(dir, "bookstore.ts"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "export interface Book {")
+}
@@ -1,16 +1,22 @@
 package tygo
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
 const defaultOutputFilename = "index.ts"
+const defaultDeclarationFilename = "index.d.ts"
 const defaultFallbackType = "any"
 const defaultPreserveComments = "default"
 const defaultEnumStyle = "const"
+const defaultCacheDir = ".tygo-cache"
 
 type PackageConfig struct {
 	// The package path just like you would import it in Go
@@ -20,6 +26,30 @@ type PackageConfig struct {
 	// If you specify a folder it will be written to a file `index.ts` within that folder. By default it is written into the Golang package folder.
 	OutputPath string `yaml:"output_path"`
 
+	// OutputLayout is how the output is split into files. "per_file" writes a file for every
+	// Go source file into the output folder, e.g. `book.go` to `book.ts`, and "per_type" a
+	// file for every type, e.g. `Book.ts`. The files import from each other.
+	// Supported values: "single" (default), "per_file", "per_type".
+	OutputLayout string `yaml:"output_layout"`
+
+	// PackageIndex writes an `index.ts` that re-exports the files of the package, if it is
+	// not generated into a single file.
+	PackageIndex bool `yaml:"package_index"`
+
+	// OutputKind is the kind of file that is generated, "dts" writes a declaration file
+	// without runtime code, where constants are declared with literal types.
+	// Supported values: "ts" (default), "dts".
+	OutputKind string `yaml:"output_kind"`
+
+	// Bundle combines the packages that share an output path into one file, either as
+	// a namespace per package or by prefixing the names of each package.
+	// Supported values: "" (no bundle), "namespace", "prefix".
+	Bundle string `yaml:"bundle"`
+
+	// BundleName is the namespace of the package in a bundle or in the index, or the prefix
+	// of its names (capitalized) in a bundle. Defaults to the Go package name.
+	BundleName string `yaml:"bundle_name"`
+
 	// Customize the indentation (use \t if you want tabs)
 	Indent string `yaml:"indent"`
 
@@ -32,11 +62,48 @@ type PackageConfig struct {
 	Frontmatter string `yaml:"frontmatter"`
 
 	// Filenames of Go source files that should not be included in the Typescript output.
+	// Glob patterns like `*_internal.go` are supported, patterns that contain a slash like
+	// `api/*.go` match the trailing path of the file. Regular expressions are prefixed with `re:`.
 	ExcludeFiles []string `yaml:"exclude_files"`
 
 	// Filenames of Go source files that should be included in the Typescript output.
+	// Supports the same patterns as ExcludeFiles.
 	IncludeFiles []string `yaml:"include_files"`
 
+	// Names of Go types that should not be included in the Typescript output, this also
+	// leaves out constants of these types. Supports the same patterns as ExcludeFiles.
+	// A single type, constant or field can also be left out with a `//tygo:ignore` comment.
+	ExcludeTypes []string `yaml:"exclude_types"`
+
+	// Names of Go types that should be included in the Typescript output, if set other
+	// types and their constants are left out. Supports the same patterns as ExcludeFiles.
+	IncludeTypes []string `yaml:"include_types"`
+
+	// Names of the Go types that are needed, if set only these types and the types they
+	// reference (also in other configured packages) are included in the output. Constants
+	// are only included if their type is. Supports the same patterns as ExcludeFiles.
+	Roots []string `yaml:"roots"`
+
+	// TypeNaming renames the types in the output, e.g. to add a `Dto` suffix. References
+	// to the types are renamed too. The `rename` option of a type takes precedence.
+	TypeNaming *TypeNaming `yaml:"type_naming"`
+
+	// ReservedNames defines what happens to types and constants whose names are reserved
+	// words in Typescript, or that shadow a global like `Record` or `Date`.
+	// Supported values: "warn" (default), "suffix", "prefix", "error".
+	// "warn" keeps the names of globals, "suffix" and "prefix" rename them to `Record_` and
+	// `_Record`. Reserved words like `delete` are always renamed, except with "error".
+	ReservedNames string `yaml:"reserved_names"`
+
+	// Types configures individual Go types, keyed by their name. Options set for a type take
+	// precedence over the options of the package.
+	Types map[string]TypeConfig `yaml:"types"`
+
+	// FieldOverrides change how struct fields are generated, keyed by `Type.Field` with the
+	// Go names of the type and field. This is useful for types that can't be given tags,
+	// like those of third-party packages.
+	FieldOverrides map[string]FieldOverride `yaml:"field_overrides"`
+
 	// FallbackType defines the Typescript type used as a fallback for unknown Go types.
 	FallbackType string `yaml:"fallback_type"`
 
@@ -46,6 +113,11 @@ type PackageConfig struct {
 	// In "yaml" mode, keys are lowercased to emulate gopkg.in/yaml.v2.
 	Flavor string `yaml:"flavor"`
 
+	// FieldNaming defines the keys of fields that have no name in their tags.
+	// Supported values: "preserve", "camel", "snake", "lower" and "kebab".
+	// By default the Go name is preserved, or lowercased in the "yaml" flavor.
+	FieldNaming string `yaml:"field_naming"`
+
 	// PreserveComments is an option to preserve comments in the generated TypeScript output.
 	// Supported values: "default", "" (same as "default"), "types", "none".
 	// By "default", package-level comments as well as type comments are
@@ -54,6 +126,13 @@ type PackageConfig struct {
 	// If "none" is supplied, no comments are preserved.
 	PreserveComments string `yaml:"preserve_comments"`
 
+	// Sort is the order of the declarations in the output. "alphabetical" sorts them by name
+	// and "topological" puts them after the declarations they refer to. The declarations of
+	// all files are sorted together, so the source file headers and package comments are
+	// left out.
+	// Supported values: "source" (default), "alphabetical", "topological".
+	Sort string `yaml:"sort"`
+
 	// Default interface for Typescript-generated interfaces to extend.
 	Extends string `yaml:"extends"`
 
@@ -69,11 +148,131 @@ type PackageConfig struct {
 	// "enum" generates TypeScript enum declarations.
 	// "union" generates TypeScript union type declarations.
 	EnumStyle string `yaml:"enum_style"`
+
+	// Strict makes generation of the package fail if any Go type had to be replaced by
+	// the fallback type, instead of only warning about it.
+	Strict bool `yaml:"strict"`
+
+	explicit explicitKeys
+	// The compiled `re:` patterns of the file and type patterns, set by Normalize. Globs map
+	// to nil.
+	regexps map[string]*regexp.Regexp
+}
+
+// TypeNaming are the rules for the names of types in the output. The replacements are
+// applied in order, then the prefix and suffix are added.
+type TypeNaming struct {
+	Prefix  string            `yaml:"prefix"`
+	Suffix  string            `yaml:"suffix"`
+	Replace []NameReplacement `yaml:"replace"`
+	// Names maps Go names of types and constants to their name in the output, no other rules
+	// are applied to these.
+	Names map[string]string `yaml:"names"`
+}
+
+// NameReplacement replaces matches of a regular expression in a name, the replacement can
+// refer to groups like `${1}`.
+type NameReplacement struct {
+	Pattern string `yaml:"pattern"`
+	With    string `yaml:"with"`
+
+	// The compiled pattern, set by Normalize.
+	re *regexp.Regexp
+}
+
+// TypeConfig holds the options for a single Go type.
+type TypeConfig struct {
+	// Rename gives the type a different name in the Typescript output, references to it
+	// are renamed too.
+	Rename string `yaml:"rename"`
+	// Interface that the generated interface extends, instead of the package's `extends`.
+	Extends string `yaml:"extends"`
+	// How constants of this type are generated, instead of the package's `enum_style`.
+	EnumStyle string `yaml:"enum_style"`
+	// Exclude leaves the type and its constants out of the output.
+	Exclude bool `yaml:"exclude"`
+	// Typescript code that is emitted before and after the declaration of the type.
+	EmitBefore string `yaml:"emit_before"`
+	EmitAfter  string `yaml:"emit_after"`
+	// Doc replaces the doc comment of the type.
+	Doc string `yaml:"doc"`
+}
+
+// FieldOverride is the config equivalent of the `json` and `tstype` tags of a struct field,
+// it takes precedence over the tags and field directives.
+type FieldOverride struct {
+	// The Typescript type of the field.
+	Type string `yaml:"type"`
+	// The key of the field in the output.
+	Name string `yaml:"name"`
+	// Optional marks the field as optional, even if it's not a pointer.
+	Optional bool `yaml:"optional"`
+	// Required marks the field as required, even if it's a pointer.
+	Required bool `yaml:"required"`
+	Readonly bool `yaml:"readonly"`
+	// Drop leaves the field out of the output.
+	Drop bool `yaml:"drop"`
 }
 
 type Config struct {
+	// Other config files to include, relative to the config file that includes them.
+	// Their contents are merged into this config, the options in this config take precedence.
+	Include []string `yaml:"include"`
+
 	TypeMappings map[string]string `yaml:"type_mappings"`
 	Packages     []*PackageConfig  `yaml:"packages"`
+
+	// PackageDefaults are options that apply to every package, the options of a package
+	// itself take precedence.
+	PackageDefaults *PackageConfig `yaml:"package_defaults"`
+
+	// Strict enables strict mode for all packages.
+	Strict bool `yaml:"strict"`
+
+	// Index writes a barrel file that re-exports the output of every package.
+	Index *IndexConfig `yaml:"index"`
+
+	// Cache skips the packages whose Go files and config have not changed since they
+	// were last generated.
+	Cache *CacheConfig `yaml:"cache"`
+
+	explicit explicitKeys
+}
+
+// CacheConfig holds the options of the cache of generated packages.
+type CacheConfig struct {
+	// The folder the cache is kept in, by default `tygo` in the user cache folder.
+	Dir string `yaml:"dir"`
+}
+
+// ResolvedDir returns the folder of the cache, which is `.tygo-cache` if there is no user
+// cache folder.
+func (c CacheConfig) ResolvedDir() string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return defaultCacheDir
+	}
+	return filepath.Join(dir, "tygo")
+}
+
+// IndexConfig holds the options of the barrel file that re-exports every generated file.
+type IndexConfig struct {
+	// Where the index is written, a folder gets an `index.ts` file.
+	Path string `yaml:"path"`
+	// How the files are re-exported. Supported values: "flat" (default) for
+	// `export * from './bookstore'` and "namespace" for `export * as bookapp from './bookstore'`.
+	Style string `yaml:"style"`
+}
+
+// ResolvedPath returns the path of the index file.
+func (c IndexConfig) ResolvedPath() string {
+	if !strings.HasSuffix(c.Path, ".ts") {
+		return filepath.Join(c.Path, defaultOutputFilename)
+	}
+	return c.Path
 }
 
 func (c Config) PackageNames() []string {
@@ -86,19 +285,29 @@ func (c Config) PackageNames() []string {
 }
 
 func (c Config) PackageConfig(packagePath string) *PackageConfig {
+	pc, err := c.packageConfig(packagePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return pc
+}
+
+// packageConfig returns the normalized config for the given package, with the package
+// defaults and global type mappings merged in.
+func (c Config) packageConfig(packagePath string) (*PackageConfig, error) {
 	for _, pc := range c.Packages {
 		if pc.Path == packagePath {
-			pc.TypeMappings = c.mergeMappings(pc.TypeMappings)
-			pcNormalized, err := pc.Normalize()
+			merged := c.withDefaults(*pc)
+			pcNormalized, err := merged.Normalize()
 			if err != nil {
-				log.Fatalf("Error in config for package %s: %s", packagePath, err)
+				return nil, fmt.Errorf("error in config for package %s: %w", packagePath, err)
 			}
+			pcNormalized.Strict = pcNormalized.Strict || c.Strict
 
-			return &pcNormalized
+			return &pcNormalized, nil
 		}
 	}
-	log.Fatalf("Config not found for package %s", packagePath)
-	return nil
+	return nil, fmt.Errorf("config not found for package %s", packagePath)
 }
 
 func normalizeFlavor(flavor string) (string, error) {
@@ -112,6 +321,73 @@ func normalizeFlavor(flavor string) (string, error) {
 	}
 }
 
+func normalizeFieldNaming(fieldNaming string, flavor string) (string, error) {
+	switch fieldNaming {
+	case "":
+		if flavor == "yaml" {
+			return "lower", nil
+		}
+		return "preserve", nil
+	case "preserve", "camel", "snake", "lower", "kebab":
+		return fieldNaming, nil
+	default:
+		return "", fmt.Errorf("unsupported field_naming: %s", fieldNaming)
+	}
+}
+
+func normalizeReservedNames(reservedNames string) (string, error) {
+	switch reservedNames {
+	case "", "warn":
+		return "warn", nil
+	case "suffix", "prefix", "error":
+		return reservedNames, nil
+	default:
+		return "", fmt.Errorf("unsupported reserved_names: %s", reservedNames)
+	}
+}
+
+func normalizeSort(order string) (string, error) {
+	switch order {
+	case "", "source":
+		return "source", nil
+	case "alphabetical", "topological":
+		return order, nil
+	default:
+		return "", fmt.Errorf("unsupported sort: %s", order)
+	}
+}
+
+func normalizeOutputLayout(outputLayout string) (string, error) {
+	switch outputLayout {
+	case "", "single":
+		return "single", nil
+	case "per_file", "per_type":
+		return outputLayout, nil
+	default:
+		return "", fmt.Errorf("unsupported output_layout: %s", outputLayout)
+	}
+}
+
+func normalizeOutputKind(outputKind string) (string, error) {
+	switch outputKind {
+	case "", "ts":
+		return "ts", nil
+	case "dts":
+		return "dts", nil
+	default:
+		return "", fmt.Errorf("unsupported output_kind: %s", outputKind)
+	}
+}
+
+func normalizeBundle(bundle string) (string, error) {
+	switch bundle {
+	case "", "namespace", "prefix":
+		return bundle, nil
+	default:
+		return "", fmt.Errorf("unsupported bundle: %s", bundle)
+	}
+}
+
 func normalizePreserveComments(preserveComments string) (string, error) {
 	switch preserveComments {
 	case "", "default":
@@ -149,10 +425,12 @@ func normalizeEnumStyle(enumStyle string) (string, error) {
 	}
 }
 
+// IsFileIgnored returns true if the Go source file should not be included in the output.
+// Files that match `exclude_files` are ignored, and if `include_files` is set only matching
+// files are included. Otherwise generated mocks (e.g. `mock_*.go`) are ignored by default.
 func (c PackageConfig) IsFileIgnored(pathToFile string) bool {
-	basename := filepath.Base(pathToFile)
 	for _, ef := range c.ExcludeFiles {
-		if basename == ef {
+		if c.matchFilePattern(ef, pathToFile) {
 			return true
 		}
 	}
@@ -160,7 +438,38 @@ func (c PackageConfig) IsFileIgnored(pathToFile string) bool {
 	// if defined, only included files are allowed
 	if len(c.IncludeFiles) > 0 {
 		for _, include := range c.IncludeFiles {
-			if basename == include {
+			if c.matchFilePattern(include, pathToFile) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, ef := range defaultExcludeFiles {
+		if c.matchFilePattern(ef, pathToFile) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTypeIgnored returns true if the Go type with the given name should not be included in
+// the output because of `exclude_types`, `include_types` or the `exclude` option of the type.
+func (c PackageConfig) IsTypeIgnored(name string) bool {
+	if c.Types[name].Exclude {
+		return true
+	}
+
+	for _, pattern := range c.ExcludeTypes {
+		if c.matchPattern(pattern, name) {
+			return true
+		}
+	}
+
+	if len(c.IncludeTypes) > 0 {
+		for _, pattern := range c.IncludeTypes {
+			if c.matchPattern(pattern, name) {
 				return false
 			}
 		}
@@ -170,16 +479,39 @@ func (c PackageConfig) IsFileIgnored(pathToFile string) bool {
 	return false
 }
 
+// ResolvedOutputPath returns the path of the output file, or of the output folder if the
+// package is not generated into a single file.
 func (c PackageConfig) ResolvedOutputPath(packageDir string) string {
+	filename := defaultOutputFilename
+	if c.OutputKind == "dts" {
+		filename = defaultDeclarationFilename
+	}
+
+	if c.OutputLayout != "single" && c.OutputLayout != "" {
+		if c.OutputPath == "" {
+			return packageDir
+		}
+		return c.OutputPath
+	}
 	if c.OutputPath == "" {
-		return filepath.Join(packageDir, defaultOutputFilename)
+		return filepath.Join(packageDir, filename)
 	} else if !strings.HasSuffix(c.OutputPath, ".ts") {
-		return filepath.Join(c.OutputPath, defaultOutputFilename)
+		return filepath.Join(c.OutputPath, filename)
 	}
 	return c.OutputPath
 }
 
+// outputDir returns the folder the package is generated into.
+func (c PackageConfig) outputDir(packageDir string) string {
+	outPath := c.ResolvedOutputPath(packageDir)
+	if c.OutputLayout != "single" && c.OutputLayout != "" {
+		return outPath
+	}
+	return filepath.Dir(outPath)
+}
+
 // Normalize returns a new PackageConfig with default values set.
+// If there are problems with the config, the returned error describes all of them.
 func (pc PackageConfig) Normalize() (PackageConfig, error) {
 	if pc.Indent == "" {
 		pc.Indent = "  "
@@ -197,30 +529,209 @@ func (pc PackageConfig) Normalize() (PackageConfig, error) {
 		pc.EnumStyle = defaultEnumStyle
 	}
 
+	var errs []string
 	var err error
 	pc.Flavor, err = normalizeFlavor(pc.Flavor)
 	if err != nil {
-		return pc, fmt.Errorf("invalid flavor config for package %s: %s", pc.Path, err)
+		errs = append(errs, fmt.Sprintf("invalid flavor config for package %s: %s", pc.Path, err))
+	}
+
+	pc.FieldNaming, err = normalizeFieldNaming(pc.FieldNaming, pc.Flavor)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid field_naming config for package %s: %s", pc.Path, err))
+	}
+
+	pc.Sort, err = normalizeSort(pc.Sort)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid sort config for package %s: %s", pc.Path, err))
+	}
+
+	pc.OutputLayout, err = normalizeOutputLayout(pc.OutputLayout)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid output_layout config for package %s: %s", pc.Path, err))
+	}
+	if pc.OutputLayout != "single" {
+		if strings.HasSuffix(pc.OutputPath, ".ts") {
+			errs = append(errs, fmt.Sprintf("invalid output_path for package %s: must be a folder with output_layout %s", pc.Path, pc.OutputLayout))
+		}
+		if pc.Bundle != "" {
+			errs = append(errs, fmt.Sprintf("invalid bundle config for package %s: can not be combined with output_layout %s", pc.Path, pc.OutputLayout))
+		}
+	}
+
+	pc.OutputKind, err = normalizeOutputKind(pc.OutputKind)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid output_kind config for package %s: %s", pc.Path, err))
+	}
+
+	pc.Bundle, err = normalizeBundle(pc.Bundle)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid bundle config for package %s: %s", pc.Path, err))
+	}
+	if pc.BundleName != "" && (!validJSName(pc.BundleName) || tsReservedWords[pc.BundleName]) {
+		errs = append(errs, fmt.Sprintf("invalid bundle_name %q for package %s: not a valid identifier", pc.BundleName, pc.Path))
+	}
+
+	pc.ReservedNames, err = normalizeReservedNames(pc.ReservedNames)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("invalid reserved_names config for package %s: %s", pc.Path, err))
 	}
 
 	pc.PreserveComments, err = normalizePreserveComments(pc.PreserveComments)
 	if err != nil {
-		return pc, fmt.Errorf("invalid preserve_comments config for package %s: %s", pc.Path, err)
+		errs = append(errs, fmt.Sprintf("invalid preserve_comments config for package %s: %s", pc.Path, err))
 	}
 
 	pc.OptionalType, err = normalizeOptionalType(pc.OptionalType)
 	if err != nil {
-		return pc, fmt.Errorf("invalid optional_type config for package %s: %s", pc.Path, err)
+		errs = append(errs, fmt.Sprintf("invalid optional_type config for package %s: %s", pc.Path, err))
 	}
 
 	pc.EnumStyle, err = normalizeEnumStyle(pc.EnumStyle)
 	if err != nil {
-		return pc, fmt.Errorf("invalid enum_style config for package %s: %s", pc.Path, err)
+		errs = append(errs, fmt.Sprintf("invalid enum_style config for package %s: %s", pc.Path, err))
+	}
+
+	pc.regexps = make(map[string]*regexp.Regexp)
+	for _, patterns := range [][]string{pc.ExcludeFiles, pc.IncludeFiles} {
+		for _, pattern := range patterns {
+			re, err := compilePattern(pattern)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("invalid file pattern %q for package %s: %s", pattern, pc.Path, err))
+			}
+			pc.regexps[pattern] = re
+		}
+	}
+	for _, patterns := range [][]string{pc.ExcludeTypes, pc.IncludeTypes, pc.Roots} {
+		for _, pattern := range patterns {
+			re, err := compilePattern(pattern)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("invalid type pattern %q for package %s: %s", pattern, pc.Path, err))
+			}
+			pc.regexps[pattern] = re
+		}
+	}
+
+	if pc.TypeNaming != nil {
+		// The rules may be shared with other packages, so the patterns are compiled into a copy.
+		tn := *pc.TypeNaming
+		tn.Replace = make([]NameReplacement, len(pc.TypeNaming.Replace))
+		for i, r := range pc.TypeNaming.Replace {
+			r.re, err = regexp.Compile(r.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("invalid type_naming pattern %q for package %s: %s", r.Pattern, pc.Path, err))
+			}
+			tn.Replace[i] = r
+		}
+		pc.TypeNaming = &tn
+		if (tn.Prefix != "" || tn.Suffix != "") && !validJSName(tn.Prefix+"T"+tn.Suffix) {
+			errs = append(errs, fmt.Sprintf("invalid type_naming prefix or suffix for package %s: names would not be valid identifiers", pc.Path))
+		}
+		goNames := make([]string, 0, len(tn.Names))
+		for goName := range tn.Names {
+			goNames = append(goNames, goName)
+		}
+		sort.Strings(goNames)
+		for _, goName := range goNames {
+			if !validJSName(tn.Names[goName]) {
+				errs = append(errs, fmt.Sprintf("invalid type_naming name %q of %s for package %s: not a valid identifier", tn.Names[goName], goName, pc.Path))
+			}
+		}
+	}
+
+	typeNames := make([]string, 0, len(pc.Types))
+	for name := range pc.Types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	types := make(map[string]TypeConfig, len(pc.Types))
+	for _, name := range typeNames {
+		tc := pc.Types[name]
+		if tc.Rename != "" && !validJSName(tc.Rename) {
+			errs = append(errs, fmt.Sprintf("invalid rename %q of type %s for package %s: not a valid identifier", tc.Rename, name, pc.Path))
+		}
+		if tc.EnumStyle != "" {
+			tc.EnumStyle, err = normalizeEnumStyle(tc.EnumStyle)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("invalid enum_style config of type %s for package %s: %s", name, pc.Path, err))
+			}
+		}
+		types[name] = tc
+	}
+	if pc.Types != nil {
+		pc.Types = types
+	}
+
+	fields := make([]string, 0, len(pc.FieldOverrides))
+	for field := range pc.FieldOverrides {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if i := strings.Index(field, "."); i <= 0 || i == len(field)-1 {
+			errs = append(errs, fmt.Sprintf("invalid field override %q for package %s: must be of the form Type.Field", field, pc.Path))
+		}
+		if fo := pc.FieldOverrides[field]; fo.Optional && fo.Required {
+			errs = append(errs, fmt.Sprintf("invalid field override %q for package %s: can't be both optional and required", field, pc.Path))
+		}
 	}
 
+	if len(errs) > 0 {
+		return pc, errors.New(strings.Join(errs, "\n"))
+	}
 	return pc, nil
 }
 
+// Validate checks the config of every package, the returned error describes all problems
+// that were found.
+func (c Config) Validate() error {
+	var errs []string
+	if c.PackageDefaults != nil && c.PackageDefaults.Path != "" {
+		errs = append(errs, "package_defaults can not have a path")
+	}
+
+	if c.Index != nil {
+		if c.Index.Path == "" {
+			errs = append(errs, "index has no path")
+		}
+		switch c.Index.Style {
+		case "", "flat", "namespace":
+		default:
+			errs = append(errs, fmt.Sprintf("invalid index config: unsupported style: %s", c.Index.Style))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, pc := range c.Packages {
+		if pc == nil || pc.Path == "" {
+			errs = append(errs, fmt.Sprintf("package %d has no path", i))
+			continue
+		}
+		if seen[pc.Path] {
+			errs = append(errs, fmt.Sprintf("package %s is configured more than once", pc.Path))
+		}
+		seen[pc.Path] = true
+
+		if _, err := c.withDefaults(*pc).Normalize(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// withDefaults returns pc with the package defaults and global type mappings applied.
+func (c Config) withDefaults(pc PackageConfig) PackageConfig {
+	if c.PackageDefaults != nil {
+		pc = mergePackageConfig(*c.PackageDefaults, pc)
+	}
+	pc.TypeMappings = c.mergeMappings(pc.TypeMappings)
+	return pc
+}
+
 func (c Config) mergeMappings(pkg map[string]string) map[string]string {
 	mappings := make(map[string]string)
 	for k, v := range c.TypeMappings {
@@ -0,0 +1,164 @@
+package tygo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFileIgnored(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		conf    PackageConfig
+		file    string
+		ignored bool
+	}{
+		{"no patterns", PackageConfig{}, "/src/pkg/book.go", false},
+		{"exact name", PackageConfig{ExcludeFiles: []string{"book.go"}}, "/src/pkg/book.go", true},
+		{"glob", PackageConfig{ExcludeFiles: []string{"*_internal.go"}}, "/src/pkg/book_internal.go", true},
+		{"glob no match", PackageConfig{ExcludeFiles: []string{"*_internal.go"}}, "/src/pkg/book.go", false},
+		{"glob prefix", PackageConfig{ExcludeFiles: []string{"zz_generated_*.go"}}, "/src/pkg/zz_generated_deepcopy.go", true},
+		{"path relative", PackageConfig{ExcludeFiles: []string{"pkg/*.go"}}, "/src/pkg/book.go", true},
+		{"path relative no match", PackageConfig{ExcludeFiles: []string{"other/*.go"}}, "/src/pkg/book.go", false},
+		{"path relative dot", PackageConfig{ExcludeFiles: []string{"./pkg/book.go"}}, "/src/pkg/book.go", true},
+		{"regexp", PackageConfig{ExcludeFiles: []string{`re:^(author|book)\.go$`}}, "/src/pkg/book.go", true},
+		{"regexp no match", PackageConfig{ExcludeFiles: []string{`re:^author\.go$`}}, "/src/pkg/book.go", false},
+		{"regexp substring", PackageConfig{ExcludeFiles: []string{`re:mock`}}, "/src/pkg/store_mocked.go", true},
+		{"regexp not folder", PackageConfig{ExcludeFiles: []string{`re:mock`}}, "/src/mocks/store.go", false},
+		{"include glob", PackageConfig{IncludeFiles: []string{"api_*.go"}}, "/src/pkg/api_book.go", false},
+		{"not included", PackageConfig{IncludeFiles: []string{"api_*.go"}}, "/src/pkg/book.go", true},
+		{"exclude wins over include", PackageConfig{IncludeFiles: []string{"*.go"}, ExcludeFiles: []string{"book.go"}}, "/src/pkg/book.go", true},
+		{"default mock exclude", PackageConfig{}, "/src/pkg/mock_store.go", true},
+		{"default mock suffix exclude", PackageConfig{}, "/src/pkg/store_mock.go", true},
+		{"mock explicitly included", PackageConfig{IncludeFiles: []string{"mock_store.go"}}, "/src/pkg/mock_store.go", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.ignored, tc.conf.IsFileIgnored(tc.file))
+		})
+	}
+}
+
+func TestNormalizeRejectsInvalidFilePatterns(t *testing.T) {
+	t.Parallel()
+
+	_, err := PackageConfig{
+		ExcludeFiles: []string{"[a-"},
+		IncludeFiles: []string{"re:(unclosed"},
+	}.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid file pattern "[a-"`)
+	assert.Contains(t, err.Error(), `invalid file pattern "re:(unclosed"`)
+}
+
+func TestNormalizeCompilesPatterns(t *testing.T) {
+	t.Parallel()
+
+	conf, err := PackageConfig{
+		ExcludeFiles: []string{"*_internal.go", `re:^legacy_`},
+		Roots:        []string{`re:Request$`},
+	}.Normalize()
+	require.NoError(t, err)
+	assert.Nil(t, conf.regexps["*_internal.go"])
+	require.NotNil(t, conf.regexps[`re:^legacy_`])
+	require.NotNil(t, conf.regexps[`re:Request$`])
+	assert.True(t, conf.IsFileIgnored("/src/pkg/legacy_book.go"))
+	assert.False(t, conf.IsFileIgnored("/src/pkg/book_legacy_.go"))
+	assert.True(t, conf.matchPattern(`re:Request$`, "ListBooksRequest"))
+}
+
+func TestNormalizeRejectsInvalidFieldOverrides(t *testing.T) {
+	t.Parallel()
+
+	_, err := PackageConfig{
+		FieldOverrides: map[string]FieldOverride{
+			"Cookie":      {Type: "string"},
+			"Cookie.":     {Type: "string"},
+			"Cookie.Name": {Optional: true, Required: true},
+			"Cookie.Path": {Optional: true},
+		},
+	}.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid field override "Cookie" for package : must be of the form Type.Field`)
+	assert.Contains(t, err.Error(), `invalid field override "Cookie." for package`)
+	assert.Contains(t, err.Error(), `invalid field override "Cookie.Name" for package : can't be both optional and required`)
+	assert.NotContains(t, err.Error(), "Cookie.Path")
+}
+
+func TestNormalizeTypes(t *testing.T) {
+	t.Parallel()
+
+	types := map[string]TypeConfig{"Genre": {EnumStyle: ""}, "Format": {EnumStyle: "union"}}
+	pc, err := PackageConfig{Types: types}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "union", pc.Types["Format"].EnumStyle)
+	assert.Equal(t, "", pc.Types["Genre"].EnumStyle)
+
+	_, err = PackageConfig{
+		Types: map[string]TypeConfig{
+			"Book":  {Rename: "Book-DTO"},
+			"Genre": {EnumStyle: "flags"},
+		},
+	}.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid rename "Book-DTO" of type Book`)
+	assert.Contains(t, err.Error(), "invalid enum_style config of type Genre for package : unsupported enum_style: flags")
+}
+
+func TestResolvedOutputPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		conf     PackageConfig
+		expected string
+	}{
+		{PackageConfig{}, "pkg/index.ts"},
+		{PackageConfig{OutputKind: "dts"}, "pkg/index.d.ts"},
+		{PackageConfig{OutputPath: "web/api"}, "web/api/index.ts"},
+		{PackageConfig{OutputPath: "web/api", OutputKind: "dts"}, "web/api/index.d.ts"},
+		{PackageConfig{OutputPath: "web/api/types.d.ts", OutputKind: "dts"}, "web/api/types.d.ts"},
+	}
+	for _, tc := range testCases {
+		conf, err := tc.conf.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, filepath.ToSlash(conf.ResolvedOutputPath("pkg")))
+	}
+
+	_, err := PackageConfig{OutputKind: "js"}.Normalize()
+	assert.ErrorContains(t, err, "unsupported output_kind: js")
+}
+
+func TestValidateIndex(t *testing.T) {
+	t.Parallel()
+
+	err := Config{Index: &IndexConfig{Style: "nested"}}.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index has no path")
+	assert.Contains(t, err.Error(), "unsupported style: nested")
+
+	assert.NoError(t, Config{Index: &IndexConfig{Path: "web", Style: "namespace"}}.Validate())
+	assert.Equal(t, filepath.Join("web", "index.ts"), IndexConfig{Path: "web"}.ResolvedPath())
+}
+
+func TestNormalizeOutputLayout(t *testing.T) {
+	t.Parallel()
+
+	conf, err := PackageConfig{OutputLayout: "per_file", OutputPath: "web/api"}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "web/api", conf.ResolvedOutputPath("pkg"))
+
+	_, err = PackageConfig{OutputLayout: "per_file", OutputPath: "web/api/types.ts", Bundle: "prefix"}.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be a folder with output_layout per_file")
+	assert.Contains(t, err.Error(), "can not be combined with output_layout per_file")
+
+	_, err = PackageConfig{OutputLayout: "nested"}.Normalize()
+	assert.ErrorContains(t, err, "unsupported output_layout: nested")
+}
@@ -2,6 +2,7 @@ package tygo
 
 import (
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/token"
 	"strings"
@@ -27,16 +28,38 @@ func ConvertGoToTypescript(goCode string, pkgConfig PackageConfig) (string, erro
 		return "", fmt.Errorf("failed to normalize package config: %w", err)
 	}
 
+	reachable, unmatchedRoots := reachableTypes([]*reachabilityPackage{
+		{path: "tygoconvert", name: "tygoconvert", files: []*ast.File{f}, conf: &pkgConfig},
+	})
+	if len(unmatchedRoots["tygoconvert"]) > 0 {
+		return "", fmt.Errorf("roots do not match any type: %s", strings.Join(unmatchedRoots["tygoconvert"], ", "))
+	}
+
 	pkgGen := &PackageGenerator{
 		conf:           &pkgConfig,
 		pkg:            nil,
 		generatedEnums: make(map[string]bool),
+		reachable:      reachable["tygoconvert"],
+		fset:           fset,
 	}
 
+	pkgGen.preProcessTypes([]*ast.File{f})
+
 	s := new(strings.Builder)
 
-	pkgGen.generateFile(s, f, "")
+	if pkgConfig.Sort != "source" {
+		pkgGen.writeSortedDecls(s, []*ast.File{f})
+	} else {
+		pkgGen.generateFile(s, f, "")
+	}
 	code := s.String()
 
+	if err := pkgGen.sourceError(); err != nil {
+		return "", err
+	}
+	if err := pkgGen.strictError(); err != nil {
+		return "", err
+	}
+
 	return code, nil
 }
@@ -0,0 +1,158 @@
+package tygo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// DiagnosticKind is the kind of problem a Diagnostic is about.
+type DiagnosticKind int
+
+const (
+	// FallbackDiagnostic is about a Go type that was replaced by the fallback type.
+	FallbackDiagnostic DiagnosticKind = iota
+	// ReservedNameDiagnostic is about a name that is reserved in Typescript or shadows
+	// a global.
+	ReservedNameDiagnostic
+	// OmittedReferenceDiagnostic is about Typescript code from a tag, directive or the config
+	// that refers to a declaration which is left out of the output.
+	OmittedReferenceDiagnostic
+)
+
+// Diagnostic is a warning about a Go type that could not be translated faithfully,
+// such as an unmapped type that was replaced by the fallback type.
+type Diagnostic struct {
+	Kind DiagnosticKind
+	// Position of the Go expression the diagnostic is about.
+	Pos token.Position
+	// Path of the declaration and fields it occurred in, e.g. `Book.Author.Name`.
+	Path string
+	// The Go type as it was written in the source, e.g. `uuid.UUID` or `func()`.
+	GoType  string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	s := new(strings.Builder)
+	if d.Pos.IsValid() {
+		s.WriteString(d.Pos.String())
+		s.WriteString(": ")
+	}
+	if d.Path != "" {
+		s.WriteString(d.Path)
+		s.WriteString(": ")
+	}
+	s.WriteString(d.Message)
+	return s.String()
+}
+
+// Diagnostics returns the diagnostics collected while generating this package.
+func (g *PackageGenerator) Diagnostics() []Diagnostic {
+	return g.diagnostics
+}
+
+func (g *PackageGenerator) pushPath(name string) {
+	g.path = append(g.path, name)
+}
+
+func (g *PackageGenerator) popPath() {
+	g.path = g.path[:len(g.path)-1]
+}
+
+func (g *PackageGenerator) position(n ast.Node) token.Position {
+	if g.fset == nil || n == nil {
+		return token.Position{}
+	}
+	return g.fset.Position(n.Pos())
+}
+
+// warnFallback records that the fallback type was written in place of the Go type t.
+func (g *PackageGenerator) warnFallback(t ast.Expr, reason string) {
+	if g.suppressDiagnostics > 0 {
+		return
+	}
+
+	goType := types.ExprString(t)
+	g.diagnostics = append(g.diagnostics, Diagnostic{
+		Kind:    FallbackDiagnostic,
+		Pos:     g.position(t),
+		Path:    strings.Join(g.path, "."),
+		GoType:  goType,
+		Message: fmt.Sprintf("%s %s, using fallback type %s", goType, reason, g.conf.FallbackType),
+	})
+}
+
+// checkTextReferences records the names that Typescript code from tags, directives or the
+// config refers to, but which are declared in the package and left out of the output.
+func (g *PackageGenerator) checkTextReferences(code string) {
+	if g.suppressDiagnostics > 0 || len(g.omitted) == 0 {
+		return
+	}
+
+	for _, name := range scanIdentifiers(code) {
+		if reason, ok := g.omitted[name]; ok {
+			g.diagnostics = append(g.diagnostics, Diagnostic{
+				Kind:    OmittedReferenceDiagnostic,
+				Path:    strings.Join(g.path, "."),
+				Message: fmt.Sprintf("refers to %s, which %s", name, reason),
+			})
+		}
+	}
+}
+
+// reportError records an error in the Go source, like a malformed struct tag, which makes
+// generation of the package fail.
+func (g *PackageGenerator) reportError(n ast.Node, err error) {
+	g.errors = append(g.errors, Diagnostic{
+		Pos:     g.position(n),
+		Path:    strings.Join(g.path, "."),
+		Message: err.Error(),
+	})
+}
+
+// sourceError returns an error listing all errors reported in the Go source, if any.
+func (g *PackageGenerator) sourceError() error {
+	if len(g.errors) == 0 {
+		return nil
+	}
+	if len(g.errors) == 1 {
+		return fmt.Errorf("%s", g.errors[0])
+	}
+
+	s := new(strings.Builder)
+	fmt.Fprintf(s, "found %d errors:", len(g.errors))
+	for _, d := range g.errors {
+		s.WriteString("\n")
+		s.WriteString(d.String())
+	}
+	return fmt.Errorf("%s", s.String())
+}
+
+// strictError returns an error listing all fallback diagnostics if the package is
+// generated in strict mode.
+func (g *PackageGenerator) strictError() error {
+	if !g.conf.Strict {
+		return nil
+	}
+
+	var fallbacks []Diagnostic
+	for _, d := range g.diagnostics {
+		if d.Kind == FallbackDiagnostic {
+			fallbacks = append(fallbacks, d)
+		}
+	}
+	if len(fallbacks) == 0 {
+		return nil
+	}
+
+	s := new(strings.Builder)
+	fmt.Fprintf(s, "strict mode does not allow fallback types, found %d:", len(fallbacks))
+	for _, d := range fallbacks {
+		s.WriteString("\n")
+		s.WriteString(d.String())
+	}
+	return fmt.Errorf("%s", s.String())
+}
@@ -0,0 +1,91 @@
+package tygo
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// isDeclarationFile returns true if the output is a `.d.ts` file, which has no runtime code.
+func (g *PackageGenerator) isDeclarationFile() bool {
+	return g.conf.OutputKind == "dts"
+}
+
+// declareKeyword returns the `declare ` modifier of top level declarations with a runtime
+// value, which is implied inside of a namespace of a declaration file.
+func (g *PackageGenerator) declareKeyword() string {
+	if !g.isDeclarationFile() || g.namespace != "" {
+		return ""
+	}
+	return "declare "
+}
+
+// literalType returns the Typescript type of a constant with the given Go value, e.g. `8`
+// for `1 << 3` and `typeof Other` for a reference to another constant. The value of `iota`
+// is that of the constant's spec. It returns false if the value has no literal type.
+func (g *PackageGenerator) literalType(value ast.Expr, iota int) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	if ident, ok := value.(*ast.Ident); ok && ident.Name != "true" && ident.Name != "false" && ident.Name != "iota" {
+		name := g.tsIdentName(ident.Name)
+		g.addReference(name)
+		return "typeof " + name, true
+	}
+
+	// Only iota is in scope, values that refer to other constants have no literal type.
+	pkg := types.NewPackage("", "")
+	pkg.Scope().Insert(types.NewConst(token.NoPos, pkg, "iota", types.Typ[types.UntypedInt], constant.MakeInt64(int64(iota))))
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	if err := types.CheckExpr(token.NewFileSet(), pkg, token.NoPos, value, info); err != nil {
+		return "", false
+	}
+	tv := info.Types[value]
+	if tv.Value == nil {
+		return "", false
+	}
+	switch tv.Value.Kind() {
+	case constant.Bool:
+		return tv.Value.String(), true
+	case constant.String:
+		if lit, ok := value.(*ast.BasicLit); ok {
+			return stringLiteral(lit.Value), true
+		}
+		return stringLiteral(strconv.Quote(constant.StringVal(tv.Value))), true
+	case constant.Int:
+		if hasDivision(value) {
+			// Integer division in Go truncates, unlike in Typescript.
+			return "number", true
+		}
+		return tv.Value.ExactString(), true
+	case constant.Float:
+		if lit, ok := value.(*ast.BasicLit); ok {
+			return lit.Value, true
+		}
+		return "number", true
+	}
+	return "", false
+}
+
+// declaredValueType returns the type of a constant in a declaration file, which is the
+// fallback type if the value has no literal type.
+func (g *PackageGenerator) declaredValueType(name *ast.Ident, value ast.Expr, iota int) string {
+	if t, ok := g.literalType(value, iota); ok {
+		return t
+	}
+	g.warnFallback(name, "has no literal type")
+	return g.conf.FallbackType
+}
+
+func hasDivision(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if b, ok := n.(*ast.BinaryExpr); ok && b.Op == token.QUO {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,42 @@
+package tygo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageError is the error for a single package that could not be generated or written.
+type PackageError struct {
+	// The package path as it was given in the config.
+	Path string
+	Err  error
+}
+
+func (e *PackageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *PackageError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateError is returned by Generate when one or more packages failed.
+// Generation continues past a failing package, so it lists every package that failed.
+type GenerateError struct {
+	Packages []*PackageError
+}
+
+func (e *GenerateError) Error() string {
+	s := new(strings.Builder)
+	if len(e.Packages) == 1 {
+		s.WriteString("1 package failed:")
+	} else {
+		fmt.Fprintf(s, "%d packages failed:", len(e.Packages))
+	}
+
+	for _, pe := range e.Packages {
+		s.WriteString("\n  ")
+		s.WriteString(strings.ReplaceAll(pe.Error(), "\n", "\n    "))
+	}
+	return s.String()
+}
@@ -34,6 +34,43 @@ func TestConvertGoToTypescriptSmoketest(t *testing.T) {
 	assert.Equal(t, expected, tsCode)
 }
 
+func TestConvertGoToTypescriptStrict(t *testing.T) {
+	t.Parallel()
+
+	goCode := `type Book struct {
+	Callback func()      ` + "`json:\"callback\"`" + `
+	ID       uuid.UUID   ` + "`json:\"id\"`" + `
+	Meta     interface{} ` + "`json:\"meta\"`" + `
+}
+
+type Box[T any] struct {
+	Value T ` + "`json:\"value\"`" + `
+}
+
+type Anything any
+
+type AnyAlias = any`
+
+	_, err := ConvertGoToTypescript(goCode, PackageConfig{})
+	require.NoError(t, err)
+
+	_, err = ConvertGoToTypescript(goCode, PackageConfig{Strict: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found 5")
+	assert.Contains(t, err.Error(), "Book.Callback: func() has no Typescript equivalent")
+	assert.Contains(t, err.Error(), "Book.ID: uuid.UUID has no type mapping")
+	assert.Contains(t, err.Error(), "Book.Meta: interface{} is an interface without type constraints")
+	assert.Contains(t, err.Error(), "Anything: any is an empty interface")
+	assert.Contains(t, err.Error(), "AnyAlias: any is an empty interface")
+
+	_, err = ConvertGoToTypescript(goCode, PackageConfig{
+		Strict:       true,
+		TypeMappings: map[string]string{"uuid.UUID": "string"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found 4")
+}
+
 func parseMarkdownFixtures(fileContents []byte) ([]MarkdownFixture, error) {
 	fixtures := make([]MarkdownFixture, 0)
 	currentFixture := MarkdownFixture{}
@@ -2,8 +2,12 @@ package tygo
 
 import (
 	"fmt"
+	"go/token"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -14,70 +18,441 @@ type Tygo struct {
 	conf *Config
 
 	packageGenerators map[string]*PackageGenerator
+	// Type mappings that apply to every package, set with SetTypeMapping.
+	typeMappings map[string]string
+	// The maximum number of output paths that are generated at the same time.
+	jobs        int
+	fset        *token.FileSet
+	diagnostics []Diagnostic
+	// The files that were generated, in the order of the packages.
+	outputs []output
+	summary WriteSummary
+}
+
+// WriteSummary lists the files that were generated by the last call to Generate.
+type WriteSummary struct {
+	// Files that were written because their content changed.
+	Written []string
+	// Files that already had the generated content, and were left alone.
+	Unchanged []string
+	// Files that tygo generated earlier into the output folder of a package that is not
+	// generated into a single file, but that are no longer generated.
+	Removed []string
 }
 
 // Responsible for generating the code for an input package
 type PackageGenerator struct {
-	conf          *PackageConfig
-	pkg           *packages.Package
-	GoFiles       []string
+	conf           *PackageConfig
+	pkg            *packages.Package
+	GoFiles        []string
 	generatedEnums map[string]bool // Track types that have been generated as enums
+
+	// The names of the types that are reachable from the configured roots, nil if all
+	// types are included.
+	reachable map[string]bool
+	// Typescript types that replace the declarations of Go types, from `//tygo:type`.
+	typeOverrides map[string]string
+	// The names in the output of the types declared in the package.
+	typeNames map[string]string
+	// The names of the constants and variables declared in the package.
+	constNames map[string]bool
+	// The reasons that declarations of the package are left out of the output, keyed by
+	// their name in the output.
+	omitted map[string]string
+
+	// The bundle the package is generated into along with other packages, if any.
+	bundle *bundle
+	// The namespace of the package in a bundle, or the prefix of its names.
+	namespace  string
+	namePrefix string
+	// The import paths of the file being generated, keyed by the name they are referred to by.
+	imports map[string]string
+	// The names that the declarations being generated refer to and whether they are used as
+	// a value, to import them from the other files of the package in the per_file layout.
+	// Nil if the package is generated into one file.
+	references   map[string]bool
+	valueContext int
+
+	fset        *token.FileSet
+	diagnostics []Diagnostic
+	errors      []Diagnostic
+	// Declaration and field names leading to what is currently being written, for diagnostics.
+	path                []string
+	suppressDiagnostics int
 }
 
 func New(config *Config) *Tygo {
 	return &Tygo{
 		conf:              config,
 		packageGenerators: make(map[string]*PackageGenerator),
+		typeMappings:      make(map[string]string),
 	}
 }
 
+// SetTypeMapping maps the Go type to the Typescript type in every package, taking
+// precedence over the type mappings in the config.
 func (g *Tygo) SetTypeMapping(goType string, tsType string) {
-	for _, p := range g.conf.Packages {
-		p.TypeMappings[goType] = tsType
-	}
+	g.typeMappings[goType] = tsType
+}
+
+// SetJobs sets the maximum number of packages that are generated at the same time, packages
+// that share an output path count as one. By default it is the number of CPUs.
+func (g *Tygo) SetJobs(jobs int) {
+	g.jobs = jobs
 }
 
+// Diagnostics returns the diagnostics of all packages generated so far.
+func (g *Tygo) Diagnostics() []Diagnostic {
+	return g.diagnostics
+}
+
+// Summary returns the files that were generated by the last call to Generate.
+func (g *Tygo) Summary() WriteSummary {
+	return g.summary
+}
+
+// Generate generates and writes the output for every configured package. Packages that
+// share an output path are generated into one file if they are bundled.
+//
+// A package that fails does not stop the others from being generated, the returned
+// error is a *GenerateError listing every package that failed and why. The index is only
+// written if every package succeeded.
 func (g *Tygo) Generate() error {
-	pkgs, err := packages.Load(&packages.Config{
-		Mode: packages.NeedSyntax | packages.NeedFiles,
-	}, g.conf.PackageNames()...)
+	g.fset = token.NewFileSet()
+	g.outputs = nil
+	g.summary = WriteSummary{}
+	mode := packages.NeedName | packages.NeedFiles
+	if g.conf.Cache == nil {
+		mode |= packages.NeedSyntax
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: mode, Fset: g.fset}, g.conf.PackageNames()...)
 	if err != nil {
 		return err
 	}
 
+	pkgConfigs := make([]*PackageConfig, len(pkgs))
+	errs := make([]error, len(pkgs))
 	for i, pkg := range pkgs {
-		if len(pkg.Errors) > 0 {
-			return fmt.Errorf("%+v", pkg.Errors)
+		pkgConfigs[i], errs[i] = g.loadedPackageConfig(pkg)
+	}
+
+	// Only the packages that are not taken from the cache are parsed.
+	var cache *generateCache
+	if g.conf.Cache != nil {
+		cache, err = g.openCache(pkgs, pkgConfigs, errs)
+		if err != nil {
+			return err
 		}
+	}
 
-		if len(pkg.GoFiles) == 0 {
-			return fmt.Errorf("no input go files for package index %d", i)
+	reachabilityPkgs := make([]*reachabilityPackage, 0, len(pkgs))
+	for i, pkg := range pkgs {
+		if errs[i] != nil || cache.isHit(pkgConfigs[i], pkg) {
+			continue
 		}
 
-		pkgConfig := g.conf.PackageConfig(pkg.ID)
+		rp := &reachabilityPackage{path: pkg.PkgPath, name: pkg.Name, conf: pkgConfigs[i]}
+		for j, file := range pkg.Syntax {
+			if !pkgConfigs[i].IsFileIgnored(pkg.GoFiles[j]) {
+				rp.files = append(rp.files, file)
+			}
+		}
+		reachabilityPkgs = append(reachabilityPkgs, rp)
+	}
+	reachable, unmatchedRoots := reachableTypes(reachabilityPkgs)
 
-		pkgGen := &PackageGenerator{
-			conf:           pkgConfig,
-			GoFiles:        pkg.GoFiles,
-			pkg:            pkg,
-			generatedEnums: make(map[string]bool),
+	for i, pkg := range pkgs {
+		if errs[i] == nil && len(unmatchedRoots[pkg.PkgPath]) > 0 {
+			errs[i] = fmt.Errorf("roots do not match any type: %s", strings.Join(unmatchedRoots[pkg.PkgPath], ", "))
 		}
-		g.packageGenerators[pkg.PkgPath] = pkgGen
-		code, err := pkgGen.Generate()
-		if err != nil {
-			return err
+	}
+	outPathOrder, outPaths := groupByOutputPath(pkgs, pkgConfigs, errs)
+
+	results := make([]generateResult, len(outPathOrder))
+	runJobs(len(outPathOrder), g.jobs, func(j int) {
+		res := &results[j]
+		indices := outPaths[outPathOrder[j]]
+		if cached, ok := cache.hit(outPathOrder[j]); ok {
+			*res = *cached
+			res.errs = make([]error, len(indices))
+			return
+		}
+		if len(indices) == 1 && pkgConfigs[indices[0]].Bundle == "" {
+			i := indices[0]
+			res.errs = []error{g.generatePackage(res, pkgs[i], pkgConfigs[i], reachable[pkgs[i].PkgPath])}
+			return
 		}
 
-		outPath := pkgGen.conf.ResolvedOutputPath(filepath.Dir(pkg.GoFiles[0]))
-		err = os.MkdirAll(filepath.Dir(outPath), os.ModePerm)
+		bundlePkgs := make([]*packages.Package, len(indices))
+		bundleConfigs := make([]*PackageConfig, len(indices))
+		for k, i := range indices {
+			bundlePkgs[k], bundleConfigs[k] = pkgs[i], pkgConfigs[i]
+		}
+		res.errs = g.generateBundle(res, outPathOrder[j], bundlePkgs, bundleConfigs, reachable)
+	})
+
+	// The results are merged in the order of the packages, regardless of when they finished.
+	for j, res := range results {
+		for k, i := range outPaths[outPathOrder[j]] {
+			errs[i] = res.errs[k]
+		}
+		for _, gen := range res.generators {
+			g.packageGenerators[gen.pkg.PkgPath] = gen
+		}
+		g.diagnostics = append(g.diagnostics, res.diagnostics...)
+		g.outputs = append(g.outputs, res.outputs...)
+		cache.save(outPathOrder[j], &results[j])
+		for _, file := range res.files {
+			g.summary.add(file.path, file.written)
+		}
+	}
+
+	// Files that are no longer generated are removed from the output folders of packages
+	// with a per_file or per_type layout, unless another package or the index generated them.
+	// Only the files tygo generated there before are removed, and none from a folder that a
+	// failed package writes into.
+	keep := make(map[string]bool)
+	for _, res := range results {
+		for _, file := range res.files {
+			keep[absPath(file.path)] = true
+		}
+	}
+	if g.conf.Index != nil {
+		keep[absPath(g.conf.Index.ResolvedPath())] = true
+	}
+	failedDirs := make(map[string]bool)
+	for i, pkg := range pkgs {
+		if errs[i] != nil && pkgConfigs[i] != nil {
+			failedDirs[absPath(pkgConfigs[i].outputDir(filepath.Dir(pkg.GoFiles[0])))] = true
+		}
+	}
+	for j, outPath := range outPathOrder {
+		i := outPaths[outPath][0]
+		if pkgConfigs[i].OutputLayout == "single" {
+			continue
+		}
+		removed, err := removeStaleFiles(outPath, results[j].files, keep, failedDirs[absPath(outPath)])
+		g.summary.Removed = append(g.summary.Removed, removed...)
 		if err != nil {
-			return nil
+			errs[i] = err
 		}
+	}
+
+	var failed []*PackageError
+	for i, pkg := range pkgs {
+		if errs[i] != nil {
+			failed = append(failed, &PackageError{Path: pkg.ID, Err: errs[i]})
+		}
+	}
 
-		err = os.WriteFile(outPath, []byte(code), 0o664)
+	if len(failed) > 0 {
+		return &GenerateError{Packages: failed}
+	}
+
+	if g.conf.Index != nil {
+		written, err := g.writeIndex()
 		if err != nil {
-			return nil
+			return fmt.Errorf("failed to write index: %w", err)
 		}
+		g.summary.add(g.conf.Index.ResolvedPath(), written)
 	}
 	return nil
 }
+
+func (s *WriteSummary) add(path string, written bool) {
+	if written {
+		s.Written = append(s.Written, path)
+	} else {
+		s.Unchanged = append(s.Unchanged, path)
+	}
+}
+
+// groupByOutputPath groups the packages without errors by their output path, in the order
+// of the packages.
+func groupByOutputPath(pkgs []*packages.Package, confs []*PackageConfig, errs []error) ([]string, map[string][]int) {
+	outPaths := make(map[string][]int)
+	var outPathOrder []string
+	for i, pkg := range pkgs {
+		if errs[i] != nil {
+			continue
+		}
+		outPath := confs[i].ResolvedOutputPath(filepath.Dir(pkg.GoFiles[0]))
+		if _, ok := outPaths[outPath]; !ok {
+			outPathOrder = append(outPathOrder, outPath)
+		}
+		outPaths[outPath] = append(outPaths[outPath], i)
+	}
+	return outPathOrder, outPaths
+}
+
+// loadedPackageConfig checks that the package was loaded correctly and returns its config.
+func (g *Tygo) loadedPackageConfig(pkg *packages.Package) (*PackageConfig, error) {
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("%+v", pkg.Errors)
+	}
+
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("no input go files")
+	}
+
+	pkgConfig, err := g.conf.packageConfig(pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	for goType, tsType := range g.typeMappings {
+		pkgConfig.TypeMappings[goType] = tsType
+	}
+	return pkgConfig, nil
+}
+
+// generateResult is what was generated for the packages of one output path. Output paths are
+// generated concurrently, so their results are only merged once all of them are done.
+type generateResult struct {
+	// The error of every package, in the order of the packages.
+	errs        []error
+	generators  []*PackageGenerator
+	diagnostics []Diagnostic
+	outputs     []output
+	// Every file that was written, including those of a package that are not in the index.
+	files []writtenFile
+}
+
+// writtenFile is a file that was generated for the packages of an output path.
+type writtenFile struct {
+	path string
+	code string
+	// False if the file already had the code.
+	written bool
+}
+
+// write writes a file for the packages of the output path.
+func (res *generateResult) write(path string, code string) error {
+	written, err := writeOutput(path, code)
+	if err != nil {
+		return err
+	}
+	res.files = append(res.files, writtenFile{path: path, code: code, written: written})
+	return nil
+}
+
+// runJobs calls fn for every index from 0 to n, running at most jobs calls at the same time.
+func runJobs(n int, jobs int, fn func(i int)) {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > n {
+		jobs = n
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// generatePackage generates the output for a single loaded package and writes it to disk.
+func (g *Tygo) generatePackage(
+	res *generateResult,
+	pkg *packages.Package,
+	pkgConfig *PackageConfig,
+	reachable map[string]bool,
+) error {
+	pkgGen := &PackageGenerator{
+		conf:           pkgConfig,
+		GoFiles:        pkg.GoFiles,
+		pkg:            pkg,
+		generatedEnums: make(map[string]bool),
+		reachable:      reachable,
+		fset:           g.fset,
+	}
+	res.generators = append(res.generators, pkgGen)
+	outPath := pkgGen.conf.ResolvedOutputPath(filepath.Dir(pkg.GoFiles[0]))
+	if pkgConfig.OutputLayout != "single" {
+		return g.generatePackageFiles(res, pkgGen, outPath)
+	}
+
+	code, err := pkgGen.Generate()
+	res.diagnostics = append(res.diagnostics, pkgGen.Diagnostics()...)
+	if err != nil {
+		return err
+	}
+
+	err = res.write(outPath, code)
+	if err != nil {
+		return err
+	}
+	res.outputs = append(res.outputs, newOutput(outPath, code, pkgGen))
+	return nil
+}
+
+// generatePackageFiles generates the files of a package that is not generated into a single
+// file, and writes them to the output folder.
+func (g *Tygo) generatePackageFiles(res *generateResult, pkgGen *PackageGenerator, outDir string) error {
+	files, err := pkgGen.generateFiles()
+	res.diagnostics = append(res.diagnostics, pkgGen.Diagnostics()...)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		path := filepath.Join(outDir, file.name)
+		if err := res.write(path, file.code); err != nil {
+			return err
+		}
+		// The index of the package re-exports its other files.
+		if !pkgGen.conf.PackageIndex || file.name == "index"+pkgGen.outputExtension() {
+			res.outputs = append(res.outputs, newOutput(path, file.code, pkgGen))
+		}
+	}
+	return nil
+}
+
+// writeOutput writes the generated code to the output path, creating its directory, and
+// returns whether it was written. A file that already has the code is left alone, so that
+// its modification time doesn't change. The code is first written to a temporary file that
+// then replaces the output, so the output is never partially written.
+func writeOutput(outPath string, code string) (bool, error) {
+	if existing, err := os.ReadFile(outPath); err == nil && string(existing) == code {
+		return false, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(outPath), os.ModePerm)
+	if err != nil {
+		return false, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(outPath), fmt.Sprintf(".%s.%d.tmp", filepath.Base(outPath), os.Getpid()))
+	err = os.WriteFile(tmpPath, []byte(code), 0o664)
+	if info, statErr := os.Stat(outPath); err == nil && statErr == nil {
+		// Keep the permissions of the file that is replaced.
+		err = os.Chmod(tmpPath, info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, outPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("failed to write output: %w", err)
+	}
+	return true, nil
+}
+
+// absPath returns the absolute path, or the path itself if it can't be made absolute.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
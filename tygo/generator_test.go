@@ -0,0 +1,501 @@
+package tygo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateReportsAllFailingPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// A file where a directory is expected makes creating the output directory fail.
+	blocker := filepath.Join(dir, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0o664))
+
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/simple",
+				OutputPath: filepath.Join(blocker, "simple.ts"),
+			},
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/rune",
+				OutputPath: filepath.Join(dir, "rune.ts"),
+			},
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/yaml",
+				OutputPath: filepath.Join(blocker, "yaml.ts"),
+			},
+		},
+	})
+
+	err := gen.Generate()
+	require.Error(t, err)
+
+	var genErr *GenerateError
+	require.True(t, errors.As(err, &genErr))
+	require.Len(t, genErr.Packages, 2)
+	assert.Equal(t, "github.com/gzuidhof/tygo/examples/simple", genErr.Packages[0].Path)
+	assert.Equal(t, "github.com/gzuidhof/tygo/examples/yaml", genErr.Packages[1].Path)
+
+	// The package in between is still generated.
+	assert.FileExists(t, filepath.Join(dir, "rune.ts"))
+}
+
+func TestGenerateRootsAcrossPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/bookstore",
+				OutputPath: filepath.Join(dir, "bookstore.ts"),
+				Roots:      []string{"Chapter"},
+			},
+			{
+				// Embeds bookapp.Book, which makes it reachable.
+				Path:         "github.com/gzuidhof/tygo/examples/embed",
+				OutputPath:   filepath.Join(dir, "embed.ts"),
+				FallbackType: "unknown",
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	b, err := os.ReadFile(filepath.Join(dir, "bookstore.ts"))
+	require.NoError(t, err)
+	out := string(b)
+
+	assert.Contains(t, out, "export interface Chapter {")
+	assert.Contains(t, out, "export interface Book {")
+	assert.Contains(t, out, "export type ISBN = ")
+	assert.NotContains(t, out, "TextBook")
+	assert.NotContains(t, out, "AuthorBookListing")
+}
+
+func TestGenerateUnmatchedRoots(t *testing.T) {
+	t.Parallel()
+
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/bookstore",
+				OutputPath: filepath.Join(t.TempDir(), "bookstore.ts"),
+				Roots:      []string{"Chapter", "Magazine"},
+			},
+		},
+	})
+	err := gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "roots do not match any type: Magazine")
+}
+
+func TestGenerateBundle(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		bundle   string
+		expected []string
+	}{
+		{
+			bundle: "namespace",
+			expected: []string{
+				"export namespace bookapp {\n",
+				"  export interface Book {\n",
+				"export namespace embed {\n",
+				"  export interface StructEmbed extends Base {\n",
+				"    book: bookapp.Book; //",
+				"    chapter?: bookapp.Chapter; //",
+			},
+		},
+		{
+			bundle: "prefix",
+			expected: []string{
+				"export interface BookappBook {\n",
+				"  chapters: BookappChapter[];\n",
+				"export interface EmbedStructEmbed extends EmbedBase {\n",
+				"  book: BookappBook; //",
+				"  chapter?: BookappChapter; //",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.bundle, func(t *testing.T) {
+			t.Parallel()
+
+			outPath := filepath.Join(t.TempDir(), "index.ts")
+			gen := New(&Config{
+				Packages: []*PackageConfig{
+					{
+						Path:         "github.com/gzuidhof/tygo/examples/bookstore",
+						OutputPath:   outPath,
+						Indent:       "  ",
+						Bundle:       tc.bundle,
+						TypeMappings: map[string]string{"uuid.UUID": "string"},
+					},
+					{
+						Path:       "github.com/gzuidhof/tygo/examples/embed",
+						OutputPath: outPath,
+						Indent:     "  ",
+						Bundle:     tc.bundle,
+					},
+				},
+			})
+			require.NoError(t, gen.Generate())
+
+			b, err := os.ReadFile(outPath)
+			require.NoError(t, err)
+			out := string(b)
+			for _, expected := range tc.expected {
+				assert.Contains(t, out, expected)
+			}
+			assert.NotContains(t, out, "bookapp.Book */")
+		})
+	}
+}
+
+func TestGenerateSharedOutputPathWithoutBundle(t *testing.T) {
+	t.Parallel()
+
+	outPath := filepath.Join(t.TempDir(), "index.ts")
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{Path: "github.com/gzuidhof/tygo/examples/bookstore", OutputPath: outPath, Bundle: "namespace"},
+			{Path: "github.com/gzuidhof/tygo/examples/embed", OutputPath: outPath},
+		},
+	})
+	err := gen.Generate()
+	require.Error(t, err)
+
+	var genErr *GenerateError
+	require.True(t, errors.As(err, &genErr))
+	require.Len(t, genErr.Packages, 2)
+	assert.Contains(t, genErr.Packages[0].Err.Error(), "set bundle to combine them into one file")
+	assert.NoFileExists(t, outPath)
+}
+
+func TestGenerateBundleNameCollision(t *testing.T) {
+	t.Parallel()
+
+	outPath := filepath.Join(t.TempDir(), "index.ts")
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{Path: "github.com/gzuidhof/tygo/examples/bookstore", OutputPath: outPath, Bundle: "prefix", BundleName: "app"},
+			{Path: "github.com/gzuidhof/tygo/examples/embed", OutputPath: outPath, Bundle: "prefix", BundleName: "App"},
+		},
+	})
+	err := gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "are both named App in the bundle")
+}
+
+func TestIndentCode(t *testing.T) {
+	t.Parallel()
+
+	code := "\n/**\n * Doc\n */\nexport const A = `line\nnext`;\nexport const B = \"`\";\n\n// `\nexport type C = string;\n"
+	expected := "\n  /**\n   * Doc\n   */\n  export const A = `line\nnext`;\n  export const B = \"`\";\n\n  // `\n  export type C = string;\n"
+	assert.Equal(t, expected, indentCode(code, "  "))
+}
+
+func TestGenerateDeclarationBundle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/simple",
+				OutputPath: dir,
+				OutputKind: "dts",
+				Bundle:     "namespace",
+			},
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/abstract",
+				OutputPath: dir,
+				OutputKind: "dts",
+				Bundle:     "namespace",
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	b, err := os.ReadFile(filepath.Join(dir, "index.d.ts"))
+	require.NoError(t, err)
+	out := string(b)
+
+	// Declarations in an ambient namespace are implicitly declared.
+	assert.Contains(t, out, "export declare namespace simple {\n")
+	assert.Contains(t, out, "  export const UserRoleDefault: UserRole;\n")
+	assert.Contains(t, out, "export declare namespace abstract {\n")
+	assert.Contains(t, out, "  export const Five: 5;\n")
+	assert.NotContains(t, out, "  export declare ")
+}
+
+func TestGenerateIndex(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		style    string
+		expected string
+	}{
+		{
+			style: "flat",
+			expected: `// Code generated by tygo. DO NOT EDIT.
+
+export * from "./simple";
+export * from "./abstract/types";
+`,
+		},
+		{
+			style: "namespace",
+			expected: `// Code generated by tygo. DO NOT EDIT.
+
+export * as simple from "./simple";
+export * as shapes from "./abstract/types";
+`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.style, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			gen := New(&Config{
+				Packages: []*PackageConfig{
+					{
+						Path:       "github.com/gzuidhof/tygo/examples/simple",
+						OutputPath: filepath.Join(dir, "simple"),
+					},
+					{
+						Path:       "github.com/gzuidhof/tygo/examples/abstract",
+						OutputPath: filepath.Join(dir, "abstract", "types.d.ts"),
+						OutputKind: "dts",
+						BundleName: "shapes",
+					},
+				},
+				Index: &IndexConfig{Path: dir, Style: tc.style},
+			})
+			require.NoError(t, gen.Generate())
+
+			b, err := os.ReadFile(filepath.Join(dir, "index.ts"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(b))
+		})
+	}
+}
+
+func TestGenerateIndexDuplicateExports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{Path: "github.com/gzuidhof/tygo/examples/embed", OutputPath: filepath.Join(dir, "embed")},
+			{Path: "github.com/gzuidhof/tygo/examples/inheritance", OutputPath: filepath.Join(dir, "inheritance")},
+		},
+		Index: &IndexConfig{Path: filepath.Join(dir, "all.ts")},
+	})
+	err := gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Base is exported by both github.com/gzuidhof/tygo/examples/embed and github.com/gzuidhof/tygo/examples/inheritance")
+	assert.NoFileExists(t, filepath.Join(dir, "all.ts"))
+
+	// Namespaces keep the names apart.
+	gen.conf.Index.Style = "namespace"
+	require.NoError(t, gen.Generate())
+	assert.FileExists(t, filepath.Join(dir, "all.ts"))
+}
+
+func TestModuleSpecifier(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "./bookstore", moduleSpecifier("/web/api/index.ts", "/web/api/bookstore/index.ts"))
+	assert.Equal(t, "./types", moduleSpecifier("/web/api/index.ts", "/web/api/types.d.ts"))
+	assert.Equal(t, "./index", moduleSpecifier("/web/api/all.ts", "/web/api/index.ts"))
+	assert.Equal(t, "../shared/types", moduleSpecifier("/web/api/index.ts", "/web/shared/types.ts"))
+	assert.Equal(t, "..", moduleSpecifier("/web/api/index.ts", "/web/index.ts"))
+}
+
+func TestGenerateConcurrently(t *testing.T) {
+	t.Parallel()
+
+	generate := func(jobs int) (map[string]string, []Diagnostic, string) {
+		dir := t.TempDir()
+		blocker := filepath.Join(dir, "blocker")
+		require.NoError(t, os.WriteFile(blocker, nil, 0o664))
+
+		var pkgs []*PackageConfig
+		for _, name := range []string{"simple", "rune", "yaml", "bookstore", "abstract", "generic", "inheritance"} {
+			pkgs = append(pkgs, &PackageConfig{
+				Path:       "github.com/gzuidhof/tygo/examples/" + name,
+				OutputPath: filepath.Join(dir, name+".ts"),
+			})
+		}
+		pkgs[2].OutputPath = filepath.Join(blocker, "yaml.ts")
+		pkgs[5].OutputPath = filepath.Join(blocker, "generic.ts")
+
+		gen := New(&Config{Packages: pkgs, Index: &IndexConfig{Path: dir}})
+		gen.SetJobs(jobs)
+		err := gen.Generate()
+
+		files := make(map[string]string)
+		entries, readErr := os.ReadDir(dir)
+		require.NoError(t, readErr)
+		for _, entry := range entries {
+			if !entry.IsDir() && entry.Name() != "blocker" {
+				b, readErr := os.ReadFile(filepath.Join(dir, entry.Name()))
+				require.NoError(t, readErr)
+				files[entry.Name()] = string(b)
+			}
+		}
+		require.Error(t, err)
+		return files, gen.Diagnostics(), strings.ReplaceAll(err.Error(), dir, "")
+	}
+
+	sequentialFiles, sequentialDiagnostics, sequentialErr := generate(1)
+	assert.Len(t, sequentialFiles, 5)
+
+	for i := 0; i < 5; i++ {
+		files, diagnostics, err := generate(8)
+		assert.Equal(t, sequentialFiles, files)
+		assert.Equal(t, sequentialDiagnostics, diagnostics)
+		assert.Equal(t, sequentialErr, err)
+	}
+}
+
+func TestSetTypeMappingKeepsConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	conf := &Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/simple",
+				OutputPath: filepath.Join(dir, "simple.ts"),
+			},
+		},
+	}
+	gen := New(conf)
+	gen.SetTypeMapping("time.Time", "Date")
+	require.NoError(t, gen.Generate())
+	assert.Nil(t, conf.Packages[0].TypeMappings)
+}
+
+func TestGenerateWritesChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "layout")
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/simple",
+				OutputPath: filepath.Join(dir, "simple.ts"),
+			},
+			{
+				Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath:   outDir,
+				OutputLayout: "per_file",
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+	summary := gen.Summary()
+	assert.Len(t, summary.Written, 4)
+	assert.Empty(t, summary.Unchanged)
+	assert.Empty(t, summary.Removed)
+	manifest, err := os.ReadFile(filepath.Join(outDir, manifestName))
+	require.NoError(t, err)
+	assert.Equal(t, codegenHeader+"author.ts\nbook.ts\ngenre.ts\n", string(manifest))
+
+	// Unchanged files are not written again.
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, path := range summary.Written {
+		require.NoError(t, os.Chtimes(path, past, past))
+	}
+	// Only the files listed in the manifest are removed, if they were generated by tygo.
+	stale := filepath.Join(outDir, "publisher.ts")
+	require.NoError(t, os.WriteFile(stale, []byte(codegenHeader+"\nexport interface Publisher {}\n"), 0o664))
+	unlisted := filepath.Join(outDir, "other.ts")
+	require.NoError(t, os.WriteFile(unlisted, []byte(codegenHeader+"\nexport interface Other {}\n"), 0o664))
+	handwritten := filepath.Join(outDir, "helpers.ts")
+	require.NoError(t, os.WriteFile(handwritten, []byte("export const x = 1;\n"), 0o664))
+	manifest = append(manifest, "helpers.ts\npublisher.ts\n"...)
+	require.NoError(t, os.WriteFile(filepath.Join(outDir, manifestName), manifest, 0o664))
+
+	require.NoError(t, gen.Generate())
+	summary = gen.Summary()
+	assert.Empty(t, summary.Written)
+	assert.Len(t, summary.Unchanged, 4)
+	assert.Equal(t, []string{stale}, summary.Removed)
+	for _, path := range summary.Unchanged {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, past, info.ModTime())
+	}
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, unlisted)
+	assert.FileExists(t, handwritten)
+
+	// A changed file is replaced without leaving a temporary file behind.
+	simple := filepath.Join(dir, "simple.ts")
+	require.NoError(t, os.WriteFile(simple, []byte("// Changed by hand\n"), 0o600))
+	require.NoError(t, os.Chmod(simple, 0o600))
+	require.NoError(t, gen.Generate())
+	assert.Equal(t, []string{simple}, gen.Summary().Written)
+	info, err := os.Stat(simple)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestGenerateKeepsFilesOfFailedPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	simple := filepath.Join(dir, "simple.ts")
+	conf := &Config{
+		Packages: []*PackageConfig{
+			{
+				Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath:   dir,
+				OutputLayout: "per_file",
+			},
+			{
+				Path:       "github.com/gzuidhof/tygo/examples/simple",
+				OutputPath: simple,
+			},
+		},
+	}
+	gen := New(conf)
+	require.NoError(t, gen.Generate())
+	assert.FileExists(t, simple)
+
+	// The file of another package in the folder is never removed, and while a package that
+	// writes into the folder fails, neither are the stale files of the layout package.
+	conf.Packages[0].ExcludeFiles = []string{"genre.go"}
+	conf.Packages[1].Strict = true
+	err := gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "examples/simple")
+	assert.Empty(t, gen.Summary().Removed)
+	assert.FileExists(t, simple)
+	assert.FileExists(t, filepath.Join(dir, "genre.ts"))
+
+	conf.Packages[1].Strict = false
+	require.NoError(t, gen.Generate())
+	assert.Equal(t, []string{filepath.Join(dir, "genre.ts")}, gen.Summary().Removed)
+	assert.FileExists(t, simple)
+}
@@ -0,0 +1,111 @@
+package tygo
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// output is a file that was generated for one or more packages.
+type output struct {
+	path     string
+	pkgPaths []string
+	// The name of the package in a namespaced index, empty if the file is a bundle.
+	indexName string
+	code      string
+}
+
+// newOutput returns the output of the given packages.
+func newOutput(path string, code string, gens ...*PackageGenerator) output {
+	out := output{path: path, code: code}
+	for _, gen := range gens {
+		out.pkgPaths = append(out.pkgPaths, gen.pkg.PkgPath)
+	}
+	if len(gens) == 1 {
+		out.indexName = gens[0].indexName()
+	}
+	return out
+}
+
+// Matches the names of top level exports, including those in emitted code.
+var exportRegexp = regexp.MustCompile(
+	`(?m)^export\s+(?:declare\s+)?(?:const\s+enum|const|let|var|enum|type|interface|namespace|function|abstract\s+class|class)\s+([\pL_$][\pL\pN_$]*)`,
+)
+
+// indexName returns the name of the package in a namespaced index.
+func (g *PackageGenerator) indexName() string {
+	if g.conf.BundleName != "" {
+		return g.conf.BundleName
+	}
+	return g.pkg.Name
+}
+
+// writeIndex writes the barrel file that re-exports every generated file, and returns whether
+// it was written. Bundles are always re-exported flat, as their packages already have a
+// namespace or prefix.
+func (g *Tygo) writeIndex() (bool, error) {
+	indexPath := g.conf.Index.ResolvedPath()
+	absIndexPath, err := filepath.Abs(indexPath)
+	if err != nil {
+		return false, err
+	}
+
+	s := new(strings.Builder)
+	s.WriteString(codegenHeader + "\n")
+
+	exportedBy := make(map[string]string)
+	var duplicates []string
+	export := func(name string, pkgPath string) {
+		if other, ok := exportedBy[name]; ok {
+			duplicates = append(duplicates, fmt.Sprintf("%s is exported by both %s and %s", name, other, pkgPath))
+			return
+		}
+		exportedBy[name] = pkgPath
+	}
+
+	for _, out := range g.outputs {
+		absPath, err := filepath.Abs(out.path)
+		if err != nil {
+			return false, err
+		}
+		if absPath == absIndexPath {
+			return false, fmt.Errorf("index %s would overwrite the output of %s", indexPath, out.pkgPaths[0])
+		}
+		spec := moduleSpecifier(absIndexPath, absPath)
+
+		if g.conf.Index.Style == "namespace" && out.indexName != "" {
+			export(out.indexName, out.pkgPaths[0])
+			fmt.Fprintf(s, "export * as %s from %q;\n", out.indexName, spec)
+			continue
+		}
+
+		for _, match := range exportRegexp.FindAllStringSubmatch(out.code, -1) {
+			export(match[1], strings.Join(out.pkgPaths, ", "))
+		}
+		fmt.Fprintf(s, "export * from %q;\n", spec)
+	}
+
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return false, fmt.Errorf("duplicate exports in index %s:\n%s", indexPath, strings.Join(duplicates, "\n"))
+	}
+	return writeOutput(indexPath, s.String())
+}
+
+// moduleSpecifier returns the relative import path of the generated file from the index,
+// e.g. `./bookstore` for `bookstore/index.ts`.
+func moduleSpecifier(indexPath string, path string) string {
+	rel, err := filepath.Rel(filepath.Dir(indexPath), path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	rel = strings.TrimSuffix(strings.TrimSuffix(rel, ".d.ts"), ".ts")
+	rel = strings.TrimSuffix(rel, "/index")
+	if rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
@@ -0,0 +1,347 @@
+package tygo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generatedFile is a file of a package that is not generated into a single file.
+type generatedFile struct {
+	// The name of the file in the output folder, e.g. `book.ts`.
+	name string
+	code string
+}
+
+// The name of the file for constants and emitted code that don't belong to a type, in the
+// per_type layout.
+const sharedChunk = "constants"
+
+// chunk is a part of the declarations of a package that is written to a file of its own.
+type chunk struct {
+	// The name of the file without its extension.
+	name       string
+	body       string
+	references map[string]bool
+}
+
+// addReference records that the declarations being generated refer to the given name in
+// the output.
+func (g *PackageGenerator) addReference(name string) {
+	if g.references != nil {
+		g.references[name] = g.references[name] || g.valueContext > 0
+	}
+}
+
+// addTextReferences records the names that Typescript code from tags, directives or the config
+// refers to, which are used as a value if the code is emitted as is.
+func (g *PackageGenerator) addTextReferences(code string, value bool) {
+	g.checkTextReferences(code)
+	if g.references == nil {
+		return
+	}
+	if value {
+		g.enterValue()
+		defer g.exitValue()
+	}
+	for _, name := range scanIdentifiers(code) {
+		g.addReference(name)
+	}
+}
+
+// enterValue marks that the references written until exitValue is called are used as a
+// value, declaration files have no values.
+func (g *PackageGenerator) enterValue() {
+	if !g.isDeclarationFile() {
+		g.valueContext++
+	}
+}
+
+func (g *PackageGenerator) exitValue() {
+	if !g.isDeclarationFile() {
+		g.valueContext--
+	}
+}
+
+// outputExtension returns the extension of the generated files.
+func (g *PackageGenerator) outputExtension() string {
+	if g.isDeclarationFile() {
+		return ".d.ts"
+	}
+	return ".ts"
+}
+
+// generateFiles generates the files of the package for the per_file or per_type layout,
+// along with the package index if it is enabled. Files without any declarations are left out.
+func (g *PackageGenerator) generateFiles() ([]generatedFile, error) {
+	files, paths := g.sourceFiles()
+	g.preProcessTypes(files)
+
+	var chunks []chunk
+	if g.conf.OutputLayout == "per_type" {
+		chunks = g.typeChunks(files, false)
+	} else {
+		chunks = g.fileChunks(files, paths)
+	}
+	g.references = nil
+
+	if err := g.sourceError(); err != nil {
+		return nil, err
+	}
+	if err := g.strictError(); err != nil {
+		return nil, err
+	}
+	return g.linkChunks(chunks)
+}
+
+// fileChunks generates a chunk for every Go source file, e.g. `book` for `book.go`.
+func (g *PackageGenerator) fileChunks(files []*ast.File, paths []string) []chunk {
+	var chunks []chunk
+	for i, file := range files {
+		g.references = make(map[string]bool)
+		s := new(strings.Builder)
+		if g.conf.Sort != "source" {
+			g.references = g.writeSortedDecls(s, []*ast.File{file})
+		} else {
+			g.generateFile(s, file, "")
+		}
+		if s.Len() > 0 {
+			chunks = append(chunks, chunk{
+				name:       strings.TrimSuffix(filepath.Base(paths[i]), ".go"),
+				body:       s.String(),
+				references: g.references,
+			})
+		}
+	}
+	return chunks
+}
+
+// typeChunks generates a chunk for every type, named after the type in the output. Constants
+// go along with their type. Constants of other types and emitted code go into the shared
+// chunk, or into a chunk of their own named after the first constant or variable if
+// separate is set.
+func (g *PackageGenerator) typeChunks(files []*ast.File, separate bool) []chunk {
+	var names []string
+	bodies := make(map[string]*strings.Builder)
+	references := make(map[string]map[string]bool)
+	write := func(name string, decl *ast.GenDecl) {
+		if bodies[name] == nil {
+			names = append(names, name)
+			bodies[name] = new(strings.Builder)
+			references[name] = make(map[string]bool)
+		}
+		g.references = references[name]
+		if decl.Tok == token.VAR {
+			g.emitVar(bodies[name], decl)
+		} else {
+			g.writeGroupDecl(bodies[name], decl)
+		}
+	}
+
+	// The shared chunk is keyed by an empty name until the end, so that a type with the same
+	// name gets a chunk of its own instead of being merged into it.
+	shared := func(decl *ast.GenDecl) string {
+		if separate {
+			return g.tsConstName(decl.Specs[0].(*ast.ValueSpec).Names[0].Name)
+		}
+		return ""
+	}
+
+	for _, file := range files {
+		if g.bundle != nil {
+			g.imports = fileImports(file, g.bundle.pkgNames)
+		}
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			switch gd.Tok {
+			case token.VAR:
+				if g.isEmitVar(gd) {
+					write(shared(gd), gd)
+				}
+			case token.CONST:
+				name := shared(gd)
+				if enumGroup := g.detectEnumGroup(gd); enumGroup != nil {
+					name = g.tsTypeName(enumGroup.typeName)
+				} else if typeName := constDeclType(gd); typeName != "" && g.emitsType(typeName) {
+					name = g.tsTypeName(typeName)
+				}
+				write(name, gd)
+			case token.TYPE:
+				// Every type of a group is written to its own file.
+				for _, spec := range gd.Specs {
+					write(g.tsTypeName(spec.(*ast.TypeSpec).Name.Name), &ast.GenDecl{
+						Doc:   gd.Doc,
+						Tok:   token.TYPE,
+						Specs: []ast.Spec{spec},
+					})
+				}
+			}
+		}
+	}
+
+	var chunks []chunk
+	for _, name := range names {
+		if bodies[name].Len() > 0 {
+			c := chunk{name: name, body: bodies[name].String(), references: references[name]}
+			if name == "" {
+				c.name = sharedChunk
+			}
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// constDeclType returns the name of the local type of all constants in the declaration, or
+// an empty string if they don't share one.
+func constDeclType(decl *ast.GenDecl) string {
+	typeName := ""
+	for i, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if vs.Type == nil && (i == 0 || len(vs.Values) > 0) {
+			return ""
+		}
+		if vs.Type == nil {
+			continue // Repeats the type of the constant before it.
+		}
+		id, ok := vs.Type.(*ast.Ident)
+		if !ok || (typeName != "" && id.Name != typeName) {
+			return ""
+		}
+		typeName = id.Name
+	}
+	return typeName
+}
+
+// linkChunks writes every chunk to a file that imports the names it refers to from the
+// other files of the package.
+func (g *PackageGenerator) linkChunks(chunks []chunk) ([]generatedFile, error) {
+	// Names that only differ in case are the same file on case-insensitive file systems.
+	names := make(map[string]string)
+	for _, c := range chunks {
+		if other, ok := names[strings.ToLower(c.name)]; ok {
+			return nil, fmt.Errorf("the output of %s and %s would be written to the same file, names that only differ in case collide on case-insensitive file systems", other, c.name)
+		}
+		names[strings.ToLower(c.name)] = c.name
+	}
+
+	ext := g.outputExtension()
+	exportedBy := make(map[string]string)
+	for _, c := range chunks {
+		for _, match := range exportRegexp.FindAllStringSubmatch(c.body, -1) {
+			exportedBy[match[1]] = c.name
+		}
+	}
+
+	files := make([]generatedFile, 0, len(chunks)+1)
+	for _, c := range chunks {
+		imports := make(map[string][]string)
+		isValue := make(map[string]bool)
+		for name, value := range c.references {
+			from, ok := exportedBy[name]
+			if !ok || from == c.name {
+				continue
+			}
+			imports[from] = append(imports[from], name)
+			isValue[from] = isValue[from] || value
+		}
+		froms := make([]string, 0, len(imports))
+		for from := range imports {
+			froms = append(froms, from)
+		}
+		sort.Strings(froms)
+
+		s := new(strings.Builder)
+		g.writeFileCodegenHeader(s)
+		g.writeFileFrontmatter(s)
+		if len(froms) > 0 {
+			s.WriteString("\n")
+		}
+		for _, from := range froms {
+			names := imports[from]
+			sort.Strings(names)
+			s.WriteString("import ")
+			if !isValue[from] {
+				// Types are erased, so the import doesn't load the other file at runtime.
+				s.WriteString("type ")
+			}
+			fmt.Fprintf(s, "{ %s } from %q;\n", strings.Join(names, ", "), "./"+from)
+		}
+		s.WriteString("\n")
+		s.WriteString(c.body)
+		files = append(files, generatedFile{name: c.name + ext, code: s.String()})
+	}
+
+	if g.conf.PackageIndex {
+		s := new(strings.Builder)
+		g.writeFileCodegenHeader(s)
+		s.WriteString("\n")
+		for _, c := range chunks {
+			if strings.EqualFold(c.name, "index") {
+				source := c.name
+				if g.conf.OutputLayout == "per_file" {
+					source += ".go"
+				}
+				return nil, fmt.Errorf("the output of %s would be overwritten by the package index", source)
+			}
+			fmt.Fprintf(s, "export * from %q;\n", "./"+c.name)
+		}
+		files = append(files, generatedFile{name: "index" + ext, code: s.String()})
+	}
+	return files, nil
+}
+
+// scanIdentifiers returns the identifiers in Typescript code, leaving out those in comments,
+// string literals and property accesses like the `Foo` in `x.Foo`.
+func scanIdentifiers(code string) []string {
+	var names []string
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '/' && i+1 < len(code) && code[i+1] == '/':
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(code) && code[i+1] == '*':
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				return names
+			}
+			i += end + 3
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		case isIdentifierStart(c):
+			start := i
+			for i+1 < len(code) && isIdentifierPart(code[i+1]) {
+				i++
+			}
+			if start == 0 || code[start-1] != '.' {
+				names = append(names, code[start:i+1])
+			}
+		case c >= '0' && c <= '9':
+			// Skip numbers like 1e3 that would otherwise be read as an identifier.
+			for i+1 < len(code) && isIdentifierPart(code[i+1]) {
+				i++
+			}
+		}
+	}
+	return names
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,279 @@
+package tygo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readOutputs returns the generated files in the folder by name, without the manifest.
+func readOutputs(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	outputs := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Name() == manifestName {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		outputs[entry.Name()] = string(b)
+	}
+	return outputs
+}
+
+func TestGeneratePerFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath:   dir,
+				OutputLayout: "per_file",
+				PackageIndex: true,
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	assert.Equal(t, map[string]string{
+		"author.ts": `// Code generated by tygo. DO NOT EDIT.
+
+import type { Book } from "./book";
+
+export interface Author {
+  name: string;
+  books: Book[];
+}
+`,
+		"book.ts": `// Code generated by tygo. DO NOT EDIT.
+
+import type { Author } from "./author";
+import type { Genre } from "./genre";
+
+export interface Book {
+  title: string;
+  genre: Genre;
+  author: Author;
+  chapters: Array<Chapter>;
+}
+export interface Chapter {
+  title: string;
+}
+`,
+		"genre.ts": `// Code generated by tygo. DO NOT EDIT.
+
+export type Genre = string;
+export const GenreNovel: Genre = "novel";
+export const GenreCrime: Genre = "crime";
+export const DefaultGenre = GenreNovel;
+`,
+		"index.ts": `// Code generated by tygo. DO NOT EDIT.
+
+export * from "./author";
+export * from "./book";
+export * from "./genre";
+`,
+	}, readOutputs(t, dir))
+}
+
+func TestGeneratePerFileValueImports(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath:   dir,
+				OutputLayout: "per_file",
+				Types: map[string]TypeConfig{
+					"Author": {EmitAfter: "export const defaultAuthor: Author = { name: '', books: [], genre: DefaultGenre };"},
+				},
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	outputs := readOutputs(t, dir)
+	assert.NotContains(t, outputs, "index.ts")
+	assert.Contains(t, outputs["author.ts"], `import type { Book } from "./book";
+import { DefaultGenre } from "./genre";
+`)
+}
+
+func TestScanIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		[]string{"Record", "Genre", "Book", "x", "y"},
+		scanIdentifiers("Record<Genre, Book['title']> // Author\n/* Chapter */ | `Shelf` | x.Foo | 1e3 | y"),
+	)
+}
+
+func TestGeneratePerType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath:   dir,
+				OutputLayout: "per_type",
+				EnumStyle:    "union",
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	assert.Equal(t, map[string]string{
+		"Author.ts": `// Code generated by tygo. DO NOT EDIT.
+
+import type { Book } from "./Book";
+
+export interface Author {
+  name: string;
+  books: Book[];
+}
+`,
+		"Book.ts": `// Code generated by tygo. DO NOT EDIT.
+
+import type { Author } from "./Author";
+import type { Chapter } from "./Chapter";
+import type { Genre } from "./Genre";
+
+export interface Book {
+  title: string;
+  genre: Genre;
+  author: Author;
+  chapters: Array<Chapter>;
+}
+`,
+		"Chapter.ts": `// Code generated by tygo. DO NOT EDIT.
+
+export interface Chapter {
+  title: string;
+}
+`,
+		"Genre.ts": `// Code generated by tygo. DO NOT EDIT.
+
+export const GenreNovel = "novel";
+export const GenreCrime = "crime";
+export type Genre = typeof GenreNovel | typeof GenreCrime;
+`,
+		"constants.ts": `// Code generated by tygo. DO NOT EDIT.
+
+import { GenreNovel } from "./Genre";
+
+export const DefaultGenre = GenreNovel;
+`,
+	}, readOutputs(t, dir))
+}
+
+func TestGeneratePerTypeNameCollisions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rename       string
+		packageIndex bool
+		msg          string
+	}{
+		"shared":     {"Constants", false, "the output of Constants and constants would be written to the same file"},
+		"exact":      {"constants", false, "the output of constants and constants would be written to the same file"},
+		"other type": {"author", false, "the output of Author and author would be written to the same file"},
+		"index":      {"Index", true, "the output of Index would be overwritten by the package index"},
+		"no index":   {"Index", false, ""},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gen := New(&Config{
+				Packages: []*PackageConfig{
+					{
+						Path:         "github.com/gzuidhof/tygo/tygo/testdata/layout",
+						OutputPath:   t.TempDir(),
+						OutputLayout: "per_type",
+						PackageIndex: tc.packageIndex,
+						Types:        map[string]TypeConfig{"Chapter": {Rename: tc.rename}},
+					},
+				},
+			})
+			err := gen.Generate()
+			if tc.msg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.msg)
+		})
+	}
+}
+
+func TestGenerateSortedAcrossFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gen := New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath: dir,
+				Sort:       "alphabetical",
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+
+	// The declarations of the files are interleaved, so there are no `// source:` headers and
+	// the package comment of book.go is left out on purpose.
+	assert.Equal(t, map[string]string{
+		"index.ts": `// Code generated by tygo. DO NOT EDIT.
+
+export interface Author {
+  name: string;
+  books: Book[];
+}
+export interface Book {
+  title: string;
+  genre: Genre;
+  author: Author;
+  chapters: Array<Chapter>;
+}
+export interface Chapter {
+  title: string;
+}
+export const DefaultGenre = GenreNovel;
+export type Genre = string;
+export const GenreNovel: Genre = "novel";
+export const GenreCrime: Genre = "crime";
+`,
+	}, readOutputs(t, dir))
+
+	// In source order, every file starts with a header.
+	dir = t.TempDir()
+	gen = New(&Config{
+		Packages: []*PackageConfig{
+			{
+				Path:       "github.com/gzuidhof/tygo/tygo/testdata/layout",
+				OutputPath: dir,
+			},
+		},
+	})
+	require.NoError(t, gen.Generate())
+	assert.Contains(t, readOutputs(t, dir)["index.ts"], `// source: book.go
+/*
+Package layout is used to test the output layouts of a package.
+*/
+`)
+}
@@ -0,0 +1,105 @@
+package tygo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestName is the name of the file that lists the files tygo generated into the output
+// folder of a package with the per_file or per_type layout.
+const manifestName = ".tygo-manifest"
+
+// readManifest returns the names of the files listed in the manifest of the folder, relative
+// to the folder. A folder without a manifest has no files listed.
+func readManifest(dir string) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" && !strings.HasPrefix(line, "//") {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// writeManifest lists the names of the files in the manifest of the folder.
+func writeManifest(dir string, names []string) error {
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString(codegenHeader)
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte('\n')
+	}
+	_, err := writeOutput(filepath.Join(dir, manifestName), sb.String())
+	return err
+}
+
+// removeStaleFiles removes the files listed in the manifest of the output folder that are
+// no longer generated, like the file of a Go source file that was deleted, and updates the
+// manifest with the generated files. Files that were generated by another package this time,
+// or no longer start with the codegen header, are left alone. If a package that writes into
+// the folder failed, nothing is removed, as its files may just not have been generated this
+// time. It returns the paths of the removed files.
+func removeStaleFiles(dir string, files []writtenFile, keep map[string]bool, failed bool) ([]string, error) {
+	listed, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(listed) == 0 && len(files) == 0 {
+		return nil, nil
+	}
+
+	generated := make(map[string]bool)
+	var names []string
+	for _, file := range files {
+		name, err := filepath.Rel(absPath(dir), absPath(file.path))
+		if err != nil {
+			continue
+		}
+		name = filepath.ToSlash(name)
+		generated[name] = true
+		names = append(names, name)
+	}
+
+	var removed []string
+	for _, name := range listed {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if generated[name] || keep[absPath(path)] {
+			continue
+		}
+		if failed {
+			// The file is listed until it can be removed.
+			names = append(names, name)
+			continue
+		}
+
+		code, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("failed to read stale file: %w", err)
+		}
+		if !strings.HasPrefix(string(code), codegenHeader) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove stale file: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, writeManifest(dir, names)
+}
@@ -0,0 +1,257 @@
+package tygo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge returns a new config with other layered on top of c.
+//
+// Type mappings are merged key by key, packages with the same path are merged field by
+// field, and any other option that is set in other takes precedence. Lists like
+// exclude_files replace the list of c instead of adding to it.
+func (c Config) Merge(other Config) Config {
+	otherPackages := other.Packages
+	other.Packages = nil
+
+	merged := c
+	mergeInto(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(other))
+
+	// Packages of other replace the package with the same path in c, duplicates within
+	// either config are left for Validate to report.
+	merged.Packages = make([]*PackageConfig, 0, len(c.Packages)+len(otherPackages))
+	index := make(map[string]int)
+	for _, pc := range c.Packages {
+		if pc != nil {
+			pcCopy := *pc
+			pc = &pcCopy
+			if _, ok := index[pc.Path]; !ok {
+				index[pc.Path] = len(merged.Packages)
+			}
+		}
+		merged.Packages = append(merged.Packages, pc)
+	}
+	for _, pc := range otherPackages {
+		if pc == nil {
+			merged.Packages = append(merged.Packages, nil)
+			continue
+		}
+		if i, ok := index[pc.Path]; ok {
+			pcMerged := mergePackageConfig(*merged.Packages[i], *pc)
+			merged.Packages[i] = &pcMerged
+			continue
+		}
+		pcCopy := *pc
+		merged.Packages = append(merged.Packages, &pcCopy)
+	}
+	return merged
+}
+
+// mergePackageConfig returns base with every option that is set in override replaced.
+func mergePackageConfig(base PackageConfig, override PackageConfig) PackageConfig {
+	mergeInto(reflect.ValueOf(&base).Elem(), reflect.ValueOf(override))
+	return base
+}
+
+// mergeInto sets every field of the struct dst that is set in override, which must be of the
+// same type. A field is set if it is not the zero value, or if its key was set in the config
+// file of override, so `strict: false` turns off strict mode. Maps are merged key by key and
+// pointers to structs are merged recursively, so the maps and structs of dst are never
+// modified in place. Slices replace the slice of dst.
+func mergeInto(dst reflect.Value, override reflect.Value) {
+	var explicit explicitKeys
+	if e, ok := override.Interface().(interface{ explicitKeys() explicitKeys }); ok {
+		explicit = e.explicitKeys()
+	}
+
+	for i := 0; i < dst.NumField(); i++ {
+		d, o := dst.Field(i), override.Field(i)
+		if !d.CanSet() {
+			continue
+		}
+
+		switch o.Kind() {
+		case reflect.Map:
+			if o.Len() == 0 {
+				continue
+			}
+			m := reflect.MakeMapWithSize(o.Type(), d.Len()+o.Len())
+			for _, src := range []reflect.Value{d, o} {
+				iter := src.MapRange()
+				for iter.Next() {
+					m.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			d.Set(m)
+		case reflect.Ptr:
+			if o.IsNil() {
+				continue
+			}
+			if d.IsNil() || o.Elem().Kind() != reflect.Struct {
+				d.Set(o)
+				continue
+			}
+			merged := reflect.New(o.Elem().Type())
+			merged.Elem().Set(d.Elem())
+			mergeInto(merged.Elem(), o.Elem())
+			d.Set(merged)
+		default:
+			if !o.IsZero() || explicit[yamlKey(dst.Type().Field(i))] {
+				d.Set(o)
+			}
+		}
+	}
+
+	if e, ok := dst.Addr().Interface().(interface{ addExplicitKeys(explicitKeys) }); ok {
+		e.addExplicitKeys(explicit)
+	}
+}
+
+// explicitKeys are the keys that were set in the config file a config was read from, to tell
+// options that are set to their zero value apart from options that are not set. Configs
+// that are not read from a file have none.
+type explicitKeys map[string]bool
+
+// union returns the keys of both, without modifying either.
+func (k explicitKeys) union(other explicitKeys) explicitKeys {
+	if len(other) == 0 {
+		return k
+	}
+	keys := make(explicitKeys, len(k)+len(other))
+	for key := range k {
+		keys[key] = true
+	}
+	for key := range other {
+		keys[key] = true
+	}
+	return keys
+}
+
+func (c Config) explicitKeys() explicitKeys {
+	return c.explicit
+}
+
+func (c *Config) addExplicitKeys(keys explicitKeys) {
+	c.explicit = c.explicit.union(keys)
+}
+
+func (c PackageConfig) explicitKeys() explicitKeys {
+	return c.explicit
+}
+
+func (c *PackageConfig) addExplicitKeys(keys explicitKeys) {
+	c.explicit = c.explicit.union(keys)
+}
+
+// UnmarshalYAML decodes the config and records which keys are set.
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plainConfig Config // Without this method
+	if err := decodeStrict(node, (*plainConfig)(c), reflect.TypeOf(*c)); err != nil {
+		return err
+	}
+	c.explicit = mappingKeys(node)
+	return nil
+}
+
+// UnmarshalYAML decodes the config and records which keys are set.
+func (c *PackageConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plainPackageConfig PackageConfig // Without this method
+	if err := decodeStrict(node, (*plainPackageConfig)(c), reflect.TypeOf(*c)); err != nil {
+		return err
+	}
+	c.explicit = mappingKeys(node)
+	return nil
+}
+
+// decodeStrict decodes the node into v and reports unknown keys like a yaml.Decoder with
+// KnownFields set, which Node.Decode doesn't do. t is the type that v stands for in errors.
+func decodeStrict(node *yaml.Node, v interface{}, t reflect.Type) error {
+	if node.Kind != yaml.MappingNode {
+		return &yaml.TypeError{Errors: []string{
+			fmt.Sprintf("line %d: cannot unmarshal %s `%s` into %s", node.Line, node.ShortTag(), node.Value, t),
+		}}
+	}
+
+	var errs []string
+	unknownStructFields(node, t, &errs)
+	if err := node.Decode(v); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return err
+		}
+		errs = append(errs, typeErr.Errors...)
+	}
+	if len(errs) > 0 {
+		return &yaml.TypeError{Errors: errs}
+	}
+	return nil
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// unknownFields adds an error for every key of a mapping in the node that is not a field of
+// the struct it is decoded into. Types that decode themselves check their own fields.
+func unknownFields(node *yaml.Node, t reflect.Type, errs *[]string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		unknownStructFields(node, t, errs)
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			unknownFields(node.Content[i], t.Elem(), errs)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, elem := range node.Content {
+			unknownFields(elem, t.Elem(), errs)
+		}
+	}
+}
+
+// unknownStructFields adds an error for every key of the mapping node that is not a field of
+// the struct type t, and checks the values of the fields.
+func unknownStructFields(node *yaml.Node, t reflect.Type, errs *[]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); key != "" && key != "-" {
+			fields[key] = t.Field(i).Type
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("line %d: field %s not found in type %s", key.Line, key.Value, t))
+			continue
+		}
+		unknownFields(value, fieldType, errs)
+	}
+}
+
+// mappingKeys returns the keys of a mapping node.
+func mappingKeys(node *yaml.Node) explicitKeys {
+	keys := make(explicitKeys)
+	for i := 0; i < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = true
+	}
+	return keys
+}
+
+// yamlKey returns the key of a struct field in a config file.
+func yamlKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
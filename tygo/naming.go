@@ -0,0 +1,150 @@
+package tygo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitWords splits a Go identifier into its words, e.g. `HTTPServerID` into `HTTP`,
+// `Server` and `ID`. Underscores separate words too.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// A new word starts at `Server` in `userServer`, and in `HTTPServer` as well.
+			if !unicode.IsUpper(prev) || nextIsLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// fieldKey returns the key of a struct field without a name in its tags, following the
+// `field_naming` option of the package.
+func fieldKey(name string, naming string) string {
+	switch naming {
+	case "lower":
+		return strings.ToLower(name)
+	case "camel":
+		words := splitWords(name)
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 {
+				w = strings.ToUpper(w[:1]) + w[1:]
+			}
+			words[i] = w
+		}
+		return strings.Join(words, "")
+	case "snake":
+		return strings.ToLower(strings.Join(splitWords(name), "_"))
+	case "kebab":
+		return strings.ToLower(strings.Join(splitWords(name), "-"))
+	default:
+		return name
+	}
+}
+
+// typeNameByRules returns the name of the Go type in the output, before reserved names are
+// resolved.
+func (c PackageConfig) typeNameByRules(goName string) string {
+	if rename := c.Types[goName].Rename; rename != "" {
+		return rename
+	}
+
+	tn := c.TypeNaming
+	if tn == nil {
+		return goName
+	}
+	if name, ok := tn.Names[goName]; ok {
+		return name
+	}
+
+	name := goName
+	for _, r := range tn.Replace {
+		if r.re != nil { // Nil if the pattern is invalid, which Normalize reports.
+			name = r.re.ReplaceAllString(name, r.With)
+		}
+	}
+	return tn.Prefix + name + tn.Suffix
+}
+
+// constNameByRules returns the name of the Go constant in the output, before reserved names
+// are resolved. Constants are only renamed by the `names` of the `type_naming` option.
+func (c PackageConfig) constNameByRules(goName string) string {
+	if c.TypeNaming != nil {
+		if name, ok := c.TypeNaming.Names[goName]; ok {
+			return name
+		}
+	}
+	return goName
+}
+
+// typeNameByRules returns the name of the Go type in the output before reserved names are
+// resolved, including the prefix of the package in a bundle.
+func (g *PackageGenerator) typeNameByRules(goName string) string {
+	return g.namePrefix + g.conf.typeNameByRules(goName)
+}
+
+// constNameByRules returns the name of the Go constant in the output before reserved names
+// are resolved, including the prefix of the package in a bundle.
+func (g *PackageGenerator) constNameByRules(goName string) string {
+	return g.namePrefix + g.conf.constNameByRules(goName)
+}
+
+// renameType returns the name of the Go type in the output, following the `rename` option
+// of the type and the `type_naming` rules of the package. Names that are reserved in
+// Typescript are resolved according to `reserved_names`.
+func (g *PackageGenerator) renameType(goName string) string {
+	name, _ := g.conf.resolveReservedName(g.typeNameByRules(goName), true)
+	return name
+}
+
+// tsTypeName returns the name of the Go type in the output. Names that are not declared as a
+// type in the package, like type parameters and builtin types, are left as is.
+func (g *PackageGenerator) tsTypeName(goName string) string {
+	if name, ok := g.typeNames[goName]; ok {
+		return name
+	}
+	if g.generatedEnums[goName] {
+		// An enum of untyped constants, which is named after their common prefix.
+		return g.renameType(goName)
+	}
+	return goName
+}
+
+// tsConstName returns the name of the Go constant in the output.
+func (g *PackageGenerator) tsConstName(goName string) string {
+	name, _ := g.conf.resolveReservedName(g.constNameByRules(goName), false)
+	return name
+}
+
+// tsIdentName returns the name in the output of an identifier that refers to either a
+// type or a constant. Other names, like builtin types, are left as is.
+func (g *PackageGenerator) tsIdentName(goName string) string {
+	if _, ok := g.typeNames[goName]; ok || g.generatedEnums[goName] {
+		return g.tsTypeName(goName)
+	}
+	if g.constNames[goName] {
+		return g.tsConstName(goName)
+	}
+	return goName
+}
@@ -0,0 +1,71 @@
+package tygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		naming string
+		key    string
+	}{
+		{"CreatedAt", "preserve", "CreatedAt"},
+		{"CreatedAt", "lower", "createdat"},
+		{"CreatedAt", "camel", "createdAt"},
+		{"CreatedAt", "snake", "created_at"},
+		{"CreatedAt", "kebab", "created-at"},
+		{"ID", "camel", "id"},
+		{"UserID", "camel", "userId"},
+		{"UserID", "snake", "user_id"},
+		{"HTTPServer", "camel", "httpServer"},
+		{"HTTPServer", "snake", "http_server"},
+		{"Address2", "snake", "address2"},
+		{"OAuth2Token", "snake", "o_auth2_token"},
+		{"Legacy_Name", "camel", "legacyName"},
+		{"Légende", "snake", "légende"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name+"/"+tc.naming, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.key, fieldKey(tc.name, tc.naming))
+		})
+	}
+}
+
+func TestTypeNamingCollision(t *testing.T) {
+	t.Parallel()
+
+	goCode := `type Book struct{}
+type BookDto struct{}
+type bookDto struct{}`
+
+	_, err := ConvertGoToTypescript(goCode, PackageConfig{
+		TypeNaming: &TypeNaming{Names: map[string]string{"Book": "BookDto", "bookDto": "BookDto"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "types Book and BookDto are both named BookDto in the output")
+}
+
+func TestNormalizeRejectsInvalidTypeNaming(t *testing.T) {
+	t.Parallel()
+
+	_, err := PackageConfig{
+		TypeNaming: &TypeNaming{
+			Prefix:  "I-",
+			Replace: []NameReplacement{{Pattern: "(unclosed", With: ""}},
+			Names:   map[string]string{"Error": "Api Error"},
+		},
+	}.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid type_naming pattern "(unclosed"`)
+	assert.Contains(t, err.Error(), "invalid type_naming prefix or suffix")
+	assert.Contains(t, err.Error(), `invalid type_naming name "Api Error" of Error`)
+}
@@ -0,0 +1,68 @@
+package tygo
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+// writeSortedDecls writes the declarations of the files in the order set by the `sort`
+// option, and returns the names they refer to. A type is kept together with its constants.
+func (g *PackageGenerator) writeSortedDecls(s *strings.Builder, files []*ast.File) map[string]bool {
+	references := make(map[string]bool)
+	for _, c := range sortChunks(g.typeChunks(files, true), g.conf.Sort) {
+		s.WriteString(c.body)
+		for name, value := range c.references {
+			references[name] = references[name] || value
+		}
+	}
+	return references
+}
+
+// sortChunks returns the chunks in alphabetical order of their names, or in topological
+// order where a chunk comes after the chunks it refers to. Chunks that don't depend on each
+// other keep their order, and so do chunks that refer to each other.
+func sortChunks(chunks []chunk, order string) []chunk {
+	sorted := make([]chunk, 0, len(chunks))
+	switch order {
+	case "alphabetical":
+		sorted = append(sorted, chunks...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i].name) < strings.ToLower(sorted[j].name)
+		})
+	case "topological":
+		exportedBy := make(map[string]int)
+		for i, c := range chunks {
+			for _, match := range exportRegexp.FindAllStringSubmatch(c.body, -1) {
+				exportedBy[match[1]] = i
+			}
+		}
+
+		visited := make([]bool, len(chunks))
+		var visit func(i int)
+		visit = func(i int) {
+			if visited[i] {
+				return
+			}
+			visited[i] = true
+
+			var dependencies []int
+			for name := range chunks[i].references {
+				if j, ok := exportedBy[name]; ok && j != i {
+					dependencies = append(dependencies, j)
+				}
+			}
+			sort.Ints(dependencies)
+			for _, j := range dependencies {
+				visit(j)
+			}
+			sorted = append(sorted, chunks[i])
+		}
+		for i := range chunks {
+			visit(i)
+		}
+	default:
+		sorted = append(sorted, chunks...)
+	}
+	return sorted
+}
@@ -1,6 +1,7 @@
 package tygo
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"strings"
@@ -19,10 +20,99 @@ func (g *PackageGenerator) preProcessEnums(file *ast.File) {
 	})
 }
 
+// preProcessTypes collects the `//tygo:type` overrides and output names of all types
+// declared in the files, and the names of their constants, so they are known before any of
+// the files is generated.
+func (g *PackageGenerator) preProcessTypes(files []*ast.File) {
+	g.typeOverrides = make(map[string]string)
+	g.typeNames = make(map[string]string)
+	g.constNames = make(map[string]bool)
+	g.omitted = make(map[string]string)
+	declaredAs := make(map[string]string)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if ok && (gd.Tok == token.CONST || gd.Tok == token.VAR) {
+				var constType ast.Expr
+				for _, spec := range gd.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if vs.Type != nil || len(vs.Values) > 0 {
+						constType = vs.Type
+					}
+					for _, name := range vs.Names {
+						g.constNames[name.Name] = true
+						if gd.Tok == token.CONST && name.IsExported() && g.isConstTypeIgnored(constType) {
+							g.omitted[g.tsConstName(name.Name)] = g.omittedConstReason(constType)
+						}
+					}
+				}
+			}
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && !gd.Lparen.IsValid() {
+					doc = gd.Doc
+				}
+				if tsType, ok := directiveValue(doc, typeDirective); ok {
+					g.typeOverrides[ts.Name.Name] = tsType
+				}
+
+				name := g.renameType(ts.Name.Name)
+				g.typeNames[ts.Name.Name] = name
+				if !ts.Name.IsExported() {
+					continue
+				}
+				if g.conf.IsTypeIgnored(ts.Name.Name) {
+					g.omitted[name] = "is excluded from the output"
+					continue
+				}
+				if !g.isReachable(ts.Name.Name) {
+					g.omitted[name] = "is not reachable from the roots"
+					continue
+				}
+				g.checkReservedName(ts, g.typeNameByRules(ts.Name.Name), true)
+				if other, ok := declaredAs[name]; ok {
+					g.reportError(ts, fmt.Errorf("types %s and %s are both named %s in the output", other, ts.Name.Name, name))
+				}
+				declaredAs[name] = ts.Name.Name
+			}
+		}
+	}
+
+	// Types that are replaced by an enum, possibly in another file, are not declared.
+	for _, file := range files {
+		g.preProcessEnums(file)
+	}
+
+	for name := range declaredAs {
+		delete(g.omitted, name)
+	}
+	g.pushPath("frontmatter")
+	g.checkTextReferences(g.conf.Frontmatter)
+	g.popPath()
+}
+
+// omittedConstReason returns why constants of type t are left out of the output.
+func (g *PackageGenerator) omittedConstReason(t ast.Expr) string {
+	if g.reachable == nil {
+		return "is left out because its type is excluded"
+	}
+	if t == nil {
+		return "is left out because untyped constants are not included with roots"
+	}
+	return "is left out because its type is not reachable from the roots"
+}
+
 // generateFile writes the generated code for a single file to the given strings.Builder.
 func (g *PackageGenerator) generateFile(s *strings.Builder, file *ast.File, filepath string) {
 	// First pass: identify types that will be generated as enums
 	g.preProcessEnums(file)
+	if g.bundle != nil {
+		g.imports = fileImports(file, g.bundle.pkgNames)
+	}
 
 	first := true
 
@@ -64,15 +154,45 @@ func (g *PackageGenerator) Generate() (string, error) {
 	g.writeFileCodegenHeader(s)
 	g.writeFileFrontmatter(s)
 
-	filepaths := g.GoFiles
+	files, _ := g.sourceFiles()
+	g.preProcessTypes(files)
+	if err := g.generateBody(s); err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
 
+// sourceFiles returns the files of the package that are not ignored, along with their paths.
+func (g *PackageGenerator) sourceFiles() ([]*ast.File, []string) {
+	var files []*ast.File
+	var paths []string
 	for i, file := range g.pkg.Syntax {
-		if g.conf.IsFileIgnored(filepaths[i]) {
+		if g.conf.IsFileIgnored(g.GoFiles[i]) {
 			continue
 		}
+		files = append(files, file)
+		paths = append(paths, g.GoFiles[i])
+	}
+	return files, paths
+}
 
-		g.generateFile(s, file, filepaths[i])
+// generateBody writes the declarations of the package, without the codegen header and
+// frontmatter of the output file. The types must have been preprocessed.
+func (g *PackageGenerator) generateBody(s *strings.Builder) error {
+	files, paths := g.sourceFiles()
+	if g.conf.Sort != "source" {
+		// The declarations of different files are mixed, so there are no source headers.
+		s.WriteString("\n")
+		g.writeSortedDecls(s, files)
+		g.references = nil
+	} else {
+		for i, file := range files {
+			g.generateFile(s, file, paths[i])
+		}
 	}
 
-	return s.String(), nil
+	if err := g.sourceError(); err != nil {
+		return err
+	}
+	return g.strictError()
 }
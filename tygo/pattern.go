@@ -0,0 +1,66 @@
+package tygo
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const regexpPatternPrefix = "re:"
+
+// Go source files that are excluded unless they are listed in `include_files`.
+var defaultExcludeFiles = []string{
+	"mock_*.go",
+	"*_mock.go",
+	"*_mocks.go",
+	"mocks.go",
+}
+
+// matchPattern reports whether s matches the pattern, which is either a glob pattern
+// (which includes plain names) or a regular expression prefixed with `re:`. Unlike a glob,
+// a regular expression matches anywhere in s unless it is anchored with `^` and `$`.
+func (c PackageConfig) matchPattern(pattern string, s string) bool {
+	re, ok := c.regexps[pattern]
+	if !ok {
+		// The pattern is compiled by Normalize, unless the config was not normalized.
+		var err error
+		if re, err = compilePattern(pattern); err != nil {
+			return false
+		}
+	}
+	if re != nil {
+		return re.MatchString(s)
+	}
+	matched, err := path.Match(pattern, s)
+	return err == nil && matched
+}
+
+// compilePattern returns the compiled regular expression of a `re:` pattern, or nil for a
+// glob pattern. It returns an error if the pattern is malformed.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, regexpPatternPrefix) {
+		return regexp.Compile(strings.TrimPrefix(pattern, regexpPatternPrefix))
+	}
+	_, err := path.Match(pattern, "")
+	return nil, err
+}
+
+// matchFilePattern reports whether the file matches the pattern. Patterns that contain a
+// slash are matched against the same number of trailing path elements (e.g. `api/*.go`),
+// other patterns against the file name.
+func (c PackageConfig) matchFilePattern(pattern string, pathToFile string) bool {
+	elems := strings.Split(filepath.ToSlash(pathToFile), "/")
+
+	name := elems[len(elems)-1]
+	if !strings.HasPrefix(pattern, regexpPatternPrefix) && strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "./")
+		n := strings.Count(pattern, "/") + 1
+		if n > len(elems) {
+			return false
+		}
+		name = strings.Join(elems[len(elems)-n:], "/")
+	}
+
+	return c.matchPattern(pattern, name)
+}
@@ -0,0 +1,218 @@
+package tygo
+
+import (
+	"go/ast"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reachabilityPackage is a package that takes part in determining which types are reachable
+// from the configured roots.
+type reachabilityPackage struct {
+	path  string
+	name  string
+	files []*ast.File
+	conf  *PackageConfig
+}
+
+// typeRef is a reference to a type declared in one of the packages.
+type typeRef struct {
+	pkgPath string
+	name    string
+}
+
+type reachabilityTypeDecl struct {
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+	file *ast.File
+}
+
+// reachableTypes returns, for every package that has roots, the names of its types that are
+// reachable from its roots or from any type of another package. Types that are referred to
+// in Typescript code of the config or the source, like `tstype` tags, field overrides and
+// the frontmatter, are reachable too. Packages without roots are not in the result, all
+// their types are emitted. The second return value holds the root patterns that did not
+// match any type, per package.
+func reachableTypes(pkgs []*reachabilityPackage) (map[string]map[string]bool, map[string][]string) {
+	decls := make(map[typeRef]reachabilityTypeDecl)
+	pkgNames := make(map[string]string)
+	confs := make(map[string]*PackageConfig)
+	// The Go names of the types of each package, keyed by their name in the output.
+	outputNames := make(map[string]map[string]string)
+	reachable := make(map[string]map[string]bool)
+	unmatched := make(map[string][]string)
+
+	for _, pkg := range pkgs {
+		pkgNames[pkg.path] = pkg.name
+		confs[pkg.path] = pkg.conf
+		outputNames[pkg.path] = make(map[string]string)
+		for _, file := range pkg.files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						doc := ts.Doc
+						if doc == nil && !gd.Lparen.IsValid() {
+							doc = gd.Doc
+						}
+						decls[typeRef{pkg.path, ts.Name.Name}] = reachabilityTypeDecl{ts, doc, file}
+						outputNames[pkg.path][pkg.conf.typeNameByRules(ts.Name.Name)] = ts.Name.Name
+					}
+				}
+			}
+		}
+		if len(pkg.conf.Roots) > 0 {
+			reachable[pkg.path] = make(map[string]bool)
+		}
+	}
+
+	var queue []typeRef
+	// appendText adds the types that the Typescript code refers to by their Go or output name.
+	appendText := func(pkgPath string, code string) {
+		for _, name := range scanIdentifiers(code) {
+			if goName, ok := outputNames[pkgPath][name]; ok {
+				name = goName
+			}
+			queue = appendIfDeclared(queue, decls, typeRef{pkgPath, name})
+		}
+	}
+
+	for _, pkg := range pkgs {
+		var names []string
+		for ref := range decls {
+			if ref.pkgPath == pkg.path {
+				names = append(names, ref.name)
+			}
+		}
+		sort.Strings(names)
+
+		if len(pkg.conf.Roots) == 0 {
+			// All types are emitted, so everything they reference in other packages is needed.
+			for _, name := range names {
+				queue = append(queue, typeRef{pkg.path, name})
+			}
+			continue
+		}
+
+		appendText(pkg.path, pkg.conf.Frontmatter)
+		appendText(pkg.path, pkg.conf.Extends)
+		for _, root := range pkg.conf.Roots {
+			found := false
+			for _, name := range names {
+				if pkg.conf.matchPattern(root, name) {
+					queue = append(queue, typeRef{pkg.path, name})
+					found = true
+				}
+			}
+			if !found {
+				unmatched[pkg.path] = append(unmatched[pkg.path], root)
+			}
+		}
+	}
+
+	visited := make(map[typeRef]bool)
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		if visited[ref] {
+			continue
+		}
+		visited[ref] = true
+		if names, ok := reachable[ref.pkgPath]; ok {
+			names[ref.name] = true
+		}
+
+		decl := decls[ref]
+		conf := confs[ref.pkgPath]
+		tc := conf.Types[ref.name]
+		appendText(ref.pkgPath, tc.Extends)
+		appendText(ref.pkgPath, tc.EmitBefore)
+		appendText(ref.pkgPath, tc.EmitAfter)
+		if tsType, ok := directiveValue(decl.doc, typeDirective); ok {
+			appendText(ref.pkgPath, tsType)
+		}
+		for key, fo := range conf.FieldOverrides {
+			if strings.HasPrefix(key, ref.name+".") {
+				appendText(ref.pkgPath, fo.Type)
+			}
+		}
+
+		imports := fileImports(decl.file, pkgNames)
+		var visit func(n ast.Node) bool
+		visit = func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				// Only the type of a field can refer to other types, not its names.
+				ast.Inspect(n.Type, visit)
+				if opts, err := getFieldOptions(n); err == nil {
+					appendText(ref.pkgPath, opts.tstype)
+				}
+				return false
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok {
+					if importPath, ok := imports[x.Name]; ok {
+						queue = appendIfDeclared(queue, decls, typeRef{importPath, n.Sel.Name})
+					}
+				}
+				return false
+			case *ast.Ident:
+				queue = appendIfDeclared(queue, decls, typeRef{ref.pkgPath, n.Name})
+			}
+			return true
+		}
+		ast.Inspect(decl.spec, visit)
+	}
+
+	return reachable, unmatched
+}
+
+func appendIfDeclared(queue []typeRef, decls map[typeRef]reachabilityTypeDecl, ref typeRef) []typeRef {
+	if _, ok := decls[ref]; ok {
+		return append(queue, ref)
+	}
+	return queue
+}
+
+// fileImports returns the import paths of the file keyed by the name they are referred to by.
+func fileImports(file *ast.File, pkgNames map[string]string) map[string]string {
+	imports := make(map[string]string)
+	if file == nil {
+		return imports
+	}
+
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		var name string
+		if imp.Name != nil {
+			name = imp.Name.Name
+		} else if pkgName, ok := pkgNames[importPath]; ok && pkgName != "" {
+			name = pkgName
+		} else {
+			name = guessPackageName(importPath)
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// guessPackageName returns the likely name of a package that was not loaded, based on
+// conventions like `github.com/x/y/v2` and `gopkg.in/guregu/null.v4`.
+func guessPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return strings.TrimPrefix(name, "go-")
+}
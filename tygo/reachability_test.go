@@ -0,0 +1,59 @@
+package tygo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOmittedReferenceDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	src := `package api
+
+const Version = "1.0"
+
+type Secret struct{}
+
+type Response struct {
+	Secret any ` + "`" + `json:"secret" tstype:"Secret"` + "`" + `
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "api.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	conf, err := PackageConfig{
+		Roots:        []string{"Response"},
+		ExcludeTypes: []string{"Secret"},
+		Frontmatter:  "export const version = Version;",
+	}.Normalize()
+	require.NoError(t, err)
+
+	reachable, _ := reachableTypes([]*reachabilityPackage{
+		{path: "api", name: "api", files: []*ast.File{f}, conf: &conf},
+	})
+	g := &PackageGenerator{
+		conf:           &conf,
+		generatedEnums: make(map[string]bool),
+		reachable:      reachable["api"],
+		fset:           fset,
+	}
+	g.preProcessTypes([]*ast.File{f})
+	g.generateFile(new(strings.Builder), f, "")
+
+	var messages []string
+	for _, d := range g.Diagnostics() {
+		assert.Equal(t, OmittedReferenceDiagnostic, d.Kind)
+		messages = append(messages, d.String())
+	}
+	assert.Equal(t, []string{
+		"frontmatter: refers to Version, which is left out because untyped constants are not included with roots",
+		"Response.Secret: refers to Secret, which is excluded from the output",
+	}, messages)
+}
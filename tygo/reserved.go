@@ -0,0 +1,113 @@
+package tygo
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// Reserved words of Typescript, which can't be the name of a type or constant.
+var tsReservedWords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "enum": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true, "import": true,
+	"in": true, "instanceof": true, "new": true, "null": true, "return": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	// Reserved in strict mode, which applies to modules.
+	"implements": true, "interface": true, "let": true, "package": true, "private": true,
+	"protected": true, "public": true, "static": true, "yield": true,
+	"await": true,
+}
+
+// Predefined types of Typescript, which can't be the name of a type.
+var tsPredefinedTypes = map[string]bool{
+	"any": true, "unknown": true, "number": true, "bigint": true, "boolean": true,
+	"string": true, "symbol": true, "never": true, "object": true, "undefined": true,
+}
+
+// Globals of the Typescript standard library, which a declaration with the same name shadows
+// in the generated file.
+var tsGlobals = map[string]bool{
+	"Object": true, "Function": true, "Array": true, "ReadonlyArray": true, "ArrayLike": true,
+	"String": true, "Number": true, "Boolean": true, "Symbol": true, "BigInt": true,
+	"Date": true, "RegExp": true, "JSON": true, "Math": true, "Intl": true,
+	"Reflect": true, "Proxy": true, "Promise": true, "PromiseLike": true, "Awaited": true,
+	"Map": true, "Set": true, "WeakMap": true, "WeakSet": true, "WeakRef": true,
+	"Iterator": true, "Iterable": true, "IterableIterator": true, "AsyncIterator": true,
+	"AsyncIterable": true, "Generator": true, "AsyncGenerator": true,
+	"Error": true, "AggregateError": true, "EvalError": true, "RangeError": true,
+	"ReferenceError": true, "SyntaxError": true, "TypeError": true, "URIError": true,
+	"ArrayBuffer": true, "SharedArrayBuffer": true, "DataView": true, "Int8Array": true,
+	"Uint8Array": true, "Uint8ClampedArray": true, "Int16Array": true, "Uint16Array": true,
+	"Int32Array": true, "Uint32Array": true, "Float32Array": true, "Float64Array": true,
+	"BigInt64Array": true, "BigUint64Array": true,
+	"Record": true, "Partial": true, "Required": true, "Readonly": true, "Pick": true,
+	"Omit": true, "Exclude": true, "Extract": true, "NonNullable": true,
+	"Parameters": true, "ConstructorParameters": true, "ReturnType": true,
+	"InstanceType": true, "ThisType": true, "PropertyKey": true,
+	"Uppercase": true, "Lowercase": true, "Capitalize": true, "Uncapitalize": true,
+	"NaN": true, "Infinity": true, "globalThis": true,
+}
+
+// reservedNameKind describes why the name of a type (or constant) can't be used as is, or
+// returns an empty string if it can.
+func reservedNameKind(name string, isType bool) string {
+	switch {
+	case tsReservedWords[name]:
+		return "is a reserved word in Typescript"
+	case isType && tsPredefinedTypes[name]:
+		return "is a predefined type in Typescript"
+	case tsGlobals[name]:
+		return "shadows the Typescript global " + name
+	}
+	return ""
+}
+
+// resolveReservedName returns the name to declare a type or constant with, following the
+// `reserved_names` option. Reserved words and predefined types are always renamed, as the
+// output would not compile otherwise. The second return value is true if the name is
+// reserved or a global.
+func (c PackageConfig) resolveReservedName(name string, isType bool) (string, bool) {
+	if reservedNameKind(name, isType) == "" {
+		return name, false
+	}
+
+	mustRename := tsReservedWords[name] || (isType && tsPredefinedTypes[name])
+	switch {
+	case c.ReservedNames == "prefix":
+		return "_" + name, true
+	case c.ReservedNames == "suffix" || mustRename:
+		return name + "_", true
+	}
+	return name, true
+}
+
+// checkReservedName warns about (or, with `reserved_names: error`, reports an error for) a
+// declaration whose name is reserved in Typescript or shadows a global.
+func (g *PackageGenerator) checkReservedName(n ast.Node, name string, isType bool) {
+	if g.suppressDiagnostics > 0 {
+		return
+	}
+	kind := reservedNameKind(name, isType)
+	if kind == "" {
+		return
+	}
+
+	if g.conf.ReservedNames == "error" {
+		g.reportError(n, fmt.Errorf("%s %s", name, kind))
+		return
+	}
+
+	msg := fmt.Sprintf("%s %s", name, kind)
+	if resolved, _ := g.conf.resolveReservedName(name, isType); resolved != name {
+		msg += ", renamed to " + resolved
+	}
+	g.diagnostics = append(g.diagnostics, Diagnostic{
+		Kind:    ReservedNameDiagnostic,
+		Pos:     g.position(n),
+		Path:    strings.Join(g.path, "."),
+		Message: msg,
+	})
+}
@@ -0,0 +1,48 @@
+package tygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedNames(t *testing.T) {
+	t.Parallel()
+
+	goCode := `type Map struct{}
+
+const NaN = 0`
+
+	// Warnings about names don't make strict mode fail.
+	_, err := ConvertGoToTypescript(goCode, PackageConfig{Strict: true})
+	require.NoError(t, err)
+
+	_, err = ConvertGoToTypescript(goCode, PackageConfig{ReservedNames: "error"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found 2 errors")
+	assert.Contains(t, err.Error(), "Map shadows the Typescript global Map")
+	assert.Contains(t, err.Error(), "NaN: NaN shadows the Typescript global NaN")
+}
+
+func TestCheckReservedName(t *testing.T) {
+	t.Parallel()
+
+	g := &PackageGenerator{conf: &PackageConfig{ReservedNames: "warn"}}
+	g.checkReservedName(nil, "Error", true)
+	g.checkReservedName(nil, "Book", true)
+	g.checkReservedName(nil, "string", true)
+	g.checkReservedName(nil, "string", false)
+	g.checkReservedName(nil, "default", false)
+
+	var messages []string
+	for _, d := range g.Diagnostics() {
+		assert.Equal(t, ReservedNameDiagnostic, d.Kind)
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"Error shadows the Typescript global Error",
+		"string is a predefined type in Typescript, renamed to string_",
+		"default is a reserved word in Typescript, renamed to default_",
+	}, messages)
+}
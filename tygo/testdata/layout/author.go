@@ -0,0 +1,6 @@
+package layout
+
+type Author struct {
+	Name  string `json:"name"`
+	Books []Book `json:"books"`
+}
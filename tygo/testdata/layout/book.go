@@ -0,0 +1,13 @@
+// Package layout is used to test the output layouts of a package.
+package layout
+
+type Book struct {
+	Title    string    `json:"title"`
+	Genre    Genre     `json:"genre"`
+	Author   Author    `json:"author"`
+	Chapters []Chapter `json:"chapters" tstype:"Array<Chapter>"`
+}
+
+type Chapter struct {
+	Title string `json:"title"`
+}
@@ -0,0 +1,3 @@
+package layout
+
+// Files without declarations are left out.
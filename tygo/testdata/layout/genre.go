@@ -0,0 +1,10 @@
+package layout
+
+type Genre string
+
+const (
+	GenreNovel Genre = "novel"
+	GenreCrime Genre = "crime"
+)
+
+const DefaultGenre = GenreNovel
@@ -0,0 +1,113 @@
+package tygo
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/fatih/structtag"
+)
+
+// tstypeTag is a parsed `tstype` struct tag, e.g. `tstype:"Record<string, number>,readonly"`.
+type tstypeTag struct {
+	// The Typescript type, empty if the type of the field is used.
+	Type    string
+	Options []string
+}
+
+func (t *tstypeTag) HasOption(opt string) bool {
+	for _, o := range t.Options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldTag parses the struct tag of the field, the `tstype` tag is nil if it is not set.
+func parseFieldTag(f *ast.Field) (*structtag.Tags, *tstypeTag, error) {
+	if f.Tag == nil {
+		return nil, nil, nil
+	}
+
+	tags, err := structtag.Parse(f.Tag.Value[1 : len(f.Tag.Value)-1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid struct tag %s: %w", f.Tag.Value, err)
+	}
+	if tags == nil {
+		return nil, nil, nil
+	}
+
+	tag, err := tags.Get("tstype")
+	if err != nil {
+		return tags, nil, nil
+	}
+	tstype, err := parseTSTypeTag(tag.Value())
+	if err != nil {
+		return nil, nil, err
+	}
+	return tags, tstype, nil
+}
+
+// parseTSTypeTag parses the value of a `tstype` tag. Commas only separate the type from the
+// options outside of brackets and string literals, so `[number, string]` and
+// `Record<string, number>` need no escaping. A type that is followed by options can also be
+// quoted in single quotes, e.g. `'Record<string, number>',readonly`.
+func parseTSTypeTag(value string) (*tstypeTag, error) {
+	var parts []string
+	var brackets []byte
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(', '[', '{', '<':
+			brackets = append(brackets, c)
+		case ')', ']', '}', '>':
+			if c == '>' && i > 0 && value[i-1] == '=' {
+				continue // The arrow of a function type.
+			}
+			open := map[byte]byte{')': '(', ']': '[', '}': '{', '>': '<'}[c]
+			if len(brackets) == 0 || brackets[len(brackets)-1] != open {
+				return nil, fmt.Errorf("invalid tstype %q: unexpected %q at offset %d", value, c, i)
+			}
+			brackets = brackets[:len(brackets)-1]
+		case ',':
+			if len(brackets) == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("invalid tstype %q: unterminated %c", value, quote)
+	}
+	if len(brackets) > 0 {
+		return nil, fmt.Errorf("invalid tstype %q: unclosed %q", value, brackets[len(brackets)-1])
+	}
+	parts = append(parts, value[start:])
+
+	tag := &tstypeTag{Type: strings.TrimSpace(parts[0])}
+	// Quotes around a type with a comma that is followed by options are only there to keep
+	// it in one piece. Without options, a quoted type is a string literal type like 'a, b'.
+	if t := tag.Type; len(parts) > 1 && len(t) >= 2 && t[0] == '\'' && t[len(t)-1] == '\'' &&
+		!strings.Contains(t[1:len(t)-1], "'") && strings.Contains(t, ",") {
+		tag.Type = t[1 : len(t)-1]
+	}
+	for _, opt := range parts[1:] {
+		tag.Options = append(tag.Options, strings.TrimSpace(opt))
+	}
+	return tag, nil
+}
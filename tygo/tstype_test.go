@@ -0,0 +1,79 @@
+package tygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTSTypeTag(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value   string
+		tsType  string
+		options []string
+	}{
+		{"string", "string", nil},
+		{",required", "", []string{"required"}},
+		{"string,readonly,required", "string", []string{"readonly", "required"}},
+		{"[number, string]", "[number, string]", nil},
+		{"Record<string, number>,readonly", "Record<string, number>", []string{"readonly"}},
+		{"{ a: number, b: string }", "{ a: number, b: string }", nil},
+		{"(a: string, b: number) => void,required", "(a: string, b: number) => void", []string{"required"}},
+		{"'a, b' | 'c'", "'a, b' | 'c'", nil},
+		{"'novel'", "'novel'", nil},
+		{"'a, b'", "'a, b'", nil},
+		{"'Record<string, number>',readonly", "Record<string, number>", []string{"readonly"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			tag, err := parseTSTypeTag(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.tsType, tag.Type)
+			assert.Equal(t, tc.options, tag.Options)
+		})
+	}
+}
+
+func TestParseTSTypeTagErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"Record<string, number":  `unclosed '<'`,
+		"[number, string]]":      `unexpected ']' at offset 16`,
+		"Array<number]":          `unexpected ']' at offset 12`,
+		"'unterminated,readonly": "unterminated '",
+	}
+
+	for value, msg := range testCases {
+		value, msg := value, msg
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseTSTypeTag(value)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), msg)
+		})
+	}
+}
+
+func TestConvertGoToTypescriptInvalidTags(t *testing.T) {
+	t.Parallel()
+
+	goCode := `type Book struct {
+	Title  string ` + "`json:\"title\" tstype:\"Array<string\"`" + `
+	Author string ` + "`json:author`" + `
+}`
+
+	_, err := ConvertGoToTypescript(goCode, PackageConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "found 2 errors")
+	assert.Contains(t, err.Error(), `Book.Title: invalid tstype "Array<string": unclosed '<'`)
+	assert.Contains(t, err.Error(), "Book.Author: invalid struct tag `json:author`")
+}
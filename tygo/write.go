@@ -8,12 +8,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/fatih/structtag"
 )
 
 var (
-	validJSNameRegexp     = regexp.MustCompile(`(?m)^[\pL_][\pL\pN_]*$`)
+	validJSNameRegexp     = regexp.MustCompile(`^[\pL_$][\pL\pN_$]*$`)
 	backquoteEscapeRegexp = regexp.MustCompile(`([$\\])`)
 	octalPrefixRegexp     = regexp.MustCompile(`^0[0-7]`)
 	unicode8Regexp        = regexp.MustCompile(`\\\\|\\U[\da-fA-F]{8}`)
@@ -38,6 +36,30 @@ func validJSName(n string) bool {
 	return validJSNameRegexp.MatchString(n)
 }
 
+var propertyNameEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`)
+
+// propertyName returns the name as the key of a property, which is quoted unless it is a
+// valid identifier. Reserved words are valid property keys, so they are left as is.
+func propertyName(name string) string {
+	if validJSName(name) {
+		return name
+	}
+	return "'" + propertyNameEscaper.Replace(name) + "'"
+}
+
+// stringLiteral returns the Go string literal as a Typescript string literal.
+func stringLiteral(value string) string {
+	if strings.HasPrefix(value, "`") {
+		return backquoteEscapeRegexp.ReplaceAllString(value, `\$1`)
+	}
+	return unicode8Regexp.ReplaceAllStringFunc(value, func(s string) string {
+		if len(s) == 10 {
+			s = fmt.Sprintf("\\u{%s}", strings.ToUpper(s[2:]))
+		}
+		return s
+	})
+}
+
 func getIdent(s string) string {
 	switch s {
 	case "bool":
@@ -90,9 +112,12 @@ func (g *PackageGenerator) writeType(
 		s.WriteByte('}')
 	case *ast.Ident:
 		if t.String() == "any" {
+			g.warnFallback(t, "is an empty interface")
 			s.WriteString(getIdent(g.conf.FallbackType))
 		} else {
-			s.WriteString(getIdent(t.String()))
+			name := g.tsIdentName(t.String())
+			g.addReference(name)
+			s.WriteString(getIdent(name))
 		}
 	case *ast.SelectorExpr:
 		// e.g. `time.Time`
@@ -100,7 +125,10 @@ func (g *PackageGenerator) writeType(
 		mappedTsType, ok := g.conf.TypeMappings[longType]
 		if ok {
 			s.WriteString(mappedTsType)
+		} else if bundled, ok := g.bundledTypeName(t); ok {
+			s.WriteString(bundled)
 		} else { // For unknown types we use the fallback type
+			g.warnFallback(t, "has no type mapping")
 			s.WriteString(g.conf.FallbackType)
 			s.WriteString(" /* ")
 			s.WriteString(longType)
@@ -113,17 +141,19 @@ func (g *PackageGenerator) writeType(
 		g.writeType(s, t.Value, t, depth, false)
 		s.WriteByte('}')
 	case *ast.BasicLit:
+		// The literal is not modified in place, the same expression can be written more than once.
+		value := t.Value
 		switch t.Kind {
 		case token.INT:
-			if octalPrefixRegexp.MatchString(t.Value) {
-				t.Value = "0o" + t.Value[1:]
+			if octalPrefixRegexp.MatchString(value) {
+				value = "0o" + value[1:]
 			}
 		case token.CHAR:
 			var char rune
-			if strings.HasPrefix(t.Value, `'\x`) ||
-				strings.HasPrefix(t.Value, `'\u`) ||
-				strings.HasPrefix(t.Value, `'\U`) {
-				i32, err := strconv.ParseInt(t.Value[3:len(t.Value)-1], 16, 32)
+			if strings.HasPrefix(value, `'\x`) ||
+				strings.HasPrefix(value, `'\u`) ||
+				strings.HasPrefix(value, `'\U`) {
+				i32, err := strconv.ParseInt(value[3:len(value)-1], 16, 32)
 				if err != nil {
 					panic(err)
 				}
@@ -131,7 +161,7 @@ func (g *PackageGenerator) writeType(
 			} else {
 				var data []byte
 				data = append(data, '"')
-				data = append(data, []byte(t.Value[1:len(t.Value)-1])...)
+				data = append(data, []byte(value[1:len(value)-1])...)
 				data = append(data, '"')
 				var s string
 				err := json.Unmarshal(data, &s)
@@ -141,23 +171,14 @@ func (g *PackageGenerator) writeType(
 				char = []rune(s)[0]
 			}
 			if char > 0xFFFF {
-				t.Value = fmt.Sprintf("0x%08X /* %s */", char, t.Value)
+				value = fmt.Sprintf("0x%08X /* %s */", char, value)
 			} else {
-				t.Value = fmt.Sprintf("0x%04X /* %s */", char, t.Value)
+				value = fmt.Sprintf("0x%04X /* %s */", char, value)
 			}
 		case token.STRING:
-			if strings.HasPrefix(t.Value, "`") {
-				t.Value = backquoteEscapeRegexp.ReplaceAllString(t.Value, `\$1`)
-			} else {
-				t.Value = unicode8Regexp.ReplaceAllStringFunc(t.Value, func(s string) string {
-					if len(s) == 10 {
-						s = fmt.Sprintf("\\u{%s}", strings.ToUpper(s[2:]))
-					}
-					return s
-				})
-			}
+			value = stringLiteral(value)
 		}
-		s.WriteString(t.Value)
+		s.WriteString(value)
 	case *ast.ParenExpr:
 		s.WriteByte('(')
 		g.writeType(s, t.X, t, depth, false)
@@ -186,8 +207,11 @@ func (g *PackageGenerator) writeType(
 			s.WriteByte(')')
 		}
 	case *ast.InterfaceType:
-		g.writeInterfaceFields(s, t.Methods.List, depth+1)
+		if !g.writeInterfaceFields(s, t.Methods.List, depth+1) {
+			g.warnFallback(t, "is an interface without type constraints")
+		}
 	case *ast.CallExpr, *ast.FuncType, *ast.ChanType:
+		g.warnFallback(t, "has no Typescript equivalent")
 		s.WriteString(g.conf.FallbackType)
 	case *ast.UnaryExpr:
 		switch t.Op {
@@ -229,6 +253,10 @@ func (g *PackageGenerator) writeType(
 }
 
 func (g *PackageGenerator) writeTypeParamsFields(s *strings.Builder, fields []*ast.Field) {
+	// A fallback type as a constraint (e.g. `T any`) does not end up in the values.
+	g.suppressDiagnostics++
+	defer func() { g.suppressDiagnostics-- }()
+
 	s.WriteByte('<')
 	for i, f := range fields {
 		for j, ident := range f.Names {
@@ -244,16 +272,18 @@ func (g *PackageGenerator) writeTypeParamsFields(s *strings.Builder, fields []*a
 	s.WriteByte('>')
 }
 
+// writeInterfaceFields writes the type constraints of an interface, it returns false if
+// there were none and the fallback type was written instead.
 func (g *PackageGenerator) writeInterfaceFields(
 	s *strings.Builder,
 	fields []*ast.Field,
 	depth int,
-) {
+) bool {
 	// Usually interfaces in Golang don't have fields, but generic (union) interfaces we can map to Typescript.
 
 	if len(fields) == 0 { // Type without any fields (probably only has methods)
 		s.WriteString(g.conf.FallbackType)
-		return
+		return false
 	}
 
 	didContainNonFuncFields := false
@@ -285,125 +315,223 @@ func (g *PackageGenerator) writeInterfaceFields(
 	if !didContainNonFuncFields {
 		s.WriteString(g.conf.FallbackType)
 	}
+	return didContainNonFuncFields
 }
 
 func (g *PackageGenerator) writeStructFields(s *strings.Builder, fields []*ast.Field, depth int) {
 	for _, f := range fields {
-		// fmt.Println(f.Type)
-		optional := false
-		required := false
-		readonly := false
-
-		fieldNames := make([]string, 0, len(f.Names))
-		if len(f.Names) == 0 { // anonymous field
-			if name, valid := getAnonymousFieldName(f.Type); valid {
-				fieldNames = append(fieldNames, name)
-			}
-		} else {
-			for _, name := range f.Names {
-				if len(name.Name) == 0 || 'A' > name.Name[0] || name.Name[0] > 'Z' {
-					continue
-				}
-				fieldNames = append(fieldNames, name.Name)
-			}
+		if hasDirective(f.Doc, ignoreDirective) {
+			continue
 		}
 
-		for _, fieldName := range fieldNames {
+		for _, fieldName := range structFieldNames(f) {
+			g.pushPath(fieldName)
+			g.writeStructField(s, f, fieldName, depth)
+			g.popPath()
+		}
+	}
+}
 
-			var name string
-			var tstype string
-			if f.Tag != nil {
-				tags, err := structtag.Parse(f.Tag.Value[1 : len(f.Tag.Value)-1])
-				if err != nil {
-					panic(err)
-				}
+// structFieldNames returns the names of the exported fields declared by f.
+func structFieldNames(f *ast.Field) []string {
+	fieldNames := make([]string, 0, len(f.Names))
+	if len(f.Names) == 0 { // anonymous field
+		if name, valid := getAnonymousFieldName(f.Type); valid {
+			fieldNames = append(fieldNames, name)
+		}
+	} else {
+		for _, name := range f.Names {
+			if len(name.Name) == 0 || 'A' > name.Name[0] || name.Name[0] > 'Z' {
+				continue
+			}
+			fieldNames = append(fieldNames, name.Name)
+		}
+	}
+	return fieldNames
+}
 
-				jsonTag, err := tags.Get("json")
-				if err == nil {
-					name = jsonTag.Name
-					if name == "-" {
-						continue
-					}
+// fieldOptions are the options of a struct field, from its tags and directives.
+type fieldOptions struct {
+	name     string
+	tstype   string
+	optional bool
+	required bool
+	readonly bool
+	// skip is true if the field is not part of the output or is inherited instead.
+	skip bool
+}
 
-					optional = jsonTag.HasOption("omitempty") || jsonTag.HasOption("omitzero")
-				}
-				yamlTag, err := tags.Get("yaml")
-				if err == nil {
-					name = yamlTag.Name
-					if name == "-" {
-						continue
-					}
-
-					optional = yamlTag.HasOption("omitempty")
-				}
+// Directives in the doc comment of a struct field, with the same meaning as the options of
+// the `json` and `tstype` tags. The type is set with `//tygo:type` like for type declarations.
+const (
+	fieldNameDirective     = "tygo:name"
+	fieldOptionalDirective = "tygo:optional"
+	fieldRequiredDirective = "tygo:required"
+	fieldReadonlyDirective = "tygo:readonly"
+)
 
-				tstypeTag, err := tags.Get("tstype")
-				if err == nil {
-					tstype = tstypeTag.Name
-					if tstype == "-" || tstypeTag.HasOption("extends") {
-						continue
-					}
-					required = tstypeTag.HasOption("required")
-					readonly = tstypeTag.HasOption("readonly")
-				}
-			}
+// getFieldOptions returns the options of a struct field. Directives take precedence over tags.
+func getFieldOptions(f *ast.Field) (fieldOptions, error) {
+	var opts fieldOptions
 
-			if len(name) == 0 {
-				if g.conf.Flavor == "yaml" {
-					name = strings.ToLower(fieldName)
-				} else {
-					name = fieldName
-				}
-			}
+	tags, tstype, err := parseFieldTag(f)
+	if err != nil {
+		return opts, err
+	}
 
-			if g.PreserveTypeComments() {
-				g.writeCommentGroupIfNotNil(s, f.Doc, depth+1)
+	if tags != nil {
+		jsonTag, err := tags.Get("json")
+		if err == nil {
+			opts.name = jsonTag.Name
+			if opts.name == "-" {
+				opts.skip = true
 			}
 
-			g.writeIndent(s, depth+1)
-			quoted := !validJSName(name)
-			if quoted {
-				s.WriteByte('\'')
-			}
-			if readonly {
-				s.WriteString("readonly ")
-			}
-			s.WriteString(name)
-			if quoted {
-				s.WriteByte('\'')
+			opts.optional = jsonTag.HasOption("omitempty") || jsonTag.HasOption("omitzero")
+		}
+		yamlTag, err := tags.Get("yaml")
+		if err == nil {
+			opts.name = yamlTag.Name
+			if opts.name == "-" {
+				opts.skip = true
 			}
 
-			switch t := f.Type.(type) {
-			case *ast.StarExpr:
-				optional = !required
-				f.Type = t.X
-			}
+			opts.optional = yamlTag.HasOption("omitempty")
+		}
+	}
 
-			if optional && g.conf.OptionalType == "undefined" {
-				s.WriteByte('?')
-			}
+	if tstype != nil {
+		opts.tstype = tstype.Type
+		if opts.tstype == "-" || tstype.HasOption("extends") {
+			opts.skip = true
+		}
+		opts.required = tstype.HasOption("required")
+		opts.readonly = tstype.HasOption("readonly")
+	}
 
-			s.WriteString(": ")
+	if tstype, ok := directiveValue(f.Doc, typeDirective); ok {
+		opts.tstype = tstype
+	}
+	if name, ok := directiveValue(f.Doc, fieldNameDirective); ok {
+		opts.name = name
+	}
+	if hasDirective(f.Doc, fieldOptionalDirective) {
+		opts.optional = true
+	}
+	if hasDirective(f.Doc, fieldRequiredDirective) {
+		opts.required = true
+	}
+	if hasDirective(f.Doc, fieldReadonlyDirective) {
+		opts.readonly = true
+	}
 
-			if tstype == "" {
-				g.writeType(s, f.Type, nil, depth, false)
-				if optional && g.conf.OptionalType == "null" {
-					s.WriteString(" | null")
-				}
-			} else {
-				s.WriteString(tstype)
-			}
-			s.WriteByte(';')
+	return opts, nil
+}
 
-			if f.Comment != nil && g.PreserveTypeComments() {
-				// Line comment is present, that means a comment after the field.
-				s.WriteString(" // ")
-				s.WriteString(f.Comment.Text())
-			} else {
-				s.WriteByte('\n')
-			}
+// apply sets the options of a field override from the config.
+func (opts *fieldOptions) apply(fo FieldOverride) {
+	if fo.Type != "" {
+		opts.tstype = fo.Type
+	}
+	if fo.Name != "" {
+		opts.name = fo.Name
+	}
+	if fo.Optional {
+		opts.optional = true
+		opts.required = false
+	}
+	if fo.Required {
+		opts.optional = false
+		opts.required = true
+	}
+	if fo.Readonly {
+		opts.readonly = true
+	}
+	if fo.Drop {
+		opts.skip = true
+	}
+}
+
+// fieldOverride returns the configured override of the field that is currently being
+// written, which is keyed by its path like `Type.Field`.
+func (g *PackageGenerator) fieldOverride() (FieldOverride, bool) {
+	fo, ok := g.conf.FieldOverrides[strings.Join(g.path, ".")]
+	return fo, ok
+}
 
+// isFieldDropped returns true if all fields declared by f are dropped by field overrides.
+func (g *PackageGenerator) isFieldDropped(f *ast.Field) bool {
+	fieldNames := structFieldNames(f)
+	for _, fieldName := range fieldNames {
+		g.pushPath(fieldName)
+		fo, ok := g.fieldOverride()
+		g.popPath()
+		if !ok || !fo.Drop {
+			return false
 		}
+	}
+	return len(fieldNames) > 0
+}
+
+// writeStructField writes a single (named) field of a struct.
+func (g *PackageGenerator) writeStructField(s *strings.Builder, f *ast.Field, fieldName string, depth int) {
+	opts, err := getFieldOptions(f)
+	if err != nil {
+		g.reportError(f, err)
+		return
+	}
+	if fo, ok := g.fieldOverride(); ok {
+		opts.apply(fo)
+	}
+	if opts.skip {
+		return
+	}
+
+	name := opts.name
+	if len(name) == 0 {
+		name = fieldKey(fieldName, g.conf.FieldNaming)
+	}
+
+	if g.PreserveTypeComments() {
+		g.writeCommentGroupIfNotNil(s, f.Doc, depth+1)
+	}
+
+	g.writeIndent(s, depth+1)
+	if opts.readonly {
+		s.WriteString("readonly ")
+	}
+	s.WriteString(propertyName(name))
+
+	optional := opts.optional
+	fieldType := f.Type
+	switch t := fieldType.(type) {
+	case *ast.StarExpr:
+		optional = !opts.required
+		fieldType = t.X
+	}
 
+	if optional && g.conf.OptionalType == "undefined" {
+		s.WriteByte('?')
+	}
+
+	s.WriteString(": ")
+
+	if opts.tstype == "" {
+		g.writeType(s, fieldType, nil, depth, false)
+		if optional && g.conf.OptionalType == "null" {
+			s.WriteString(" | null")
+		}
+	} else {
+		g.addTextReferences(opts.tstype, false)
+		s.WriteString(opts.tstype)
+	}
+	s.WriteByte(';')
+
+	if f.Comment != nil && g.PreserveTypeComments() {
+		// Line comment is present, that means a comment after the field.
+		s.WriteString(" // ")
+		s.WriteString(f.Comment.Text())
+	} else {
+		s.WriteByte('\n')
 	}
 }
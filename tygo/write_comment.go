@@ -23,10 +23,52 @@ func (g *PackageGenerator) writeCommentGroupIfNotNil(s *strings.Builder, f *ast.
 	}
 }
 
+// hasDirective returns true if the comment group contains the given tygo directive,
+// e.g. `//tygo:ignore`.
+func hasDirective(cg *ast.CommentGroup, directive string) bool {
+	if cg == nil {
+		return false
+	}
+	for _, c := range cg.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		if text == directive || strings.HasPrefix(text, directive+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveValue returns the text that follows the given tygo directive in the comment
+// group, e.g. `number` for `//tygo:type number`.
+func directiveValue(cg *ast.CommentGroup, directive string) (string, bool) {
+	if cg == nil {
+		return "", false
+	}
+	for _, c := range cg.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		if strings.HasPrefix(text, directive+" ") {
+			return strings.TrimSpace(strings.TrimPrefix(text, directive)), true
+		}
+	}
+	return "", false
+}
+
+// onlyTygoDirectives returns true if the comment group consists of tygo directives that
+// don't emit anything themselves, like `//tygo:type`.
+func onlyTygoDirectives(cg *ast.CommentGroup) bool {
+	for _, c := range cg.List {
+		if !strings.HasPrefix(c.Text, "//tygo:") || strings.HasPrefix(c.Text, "//tygo:emit") {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *PackageGenerator) writeDirective(s *strings.Builder, cg *ast.CommentGroup) {
 	for _, cm := range cg.List {
 		if strings.HasPrefix(cm.Text, "//tygo:emit") {
 			// remove the separator whitespace but leave extra whitespace for indentation
+			c.addTextReferences(strings.TrimPrefix(cm.Text, "//tygo:emit")[1:], true)
 			s.WriteString(strings.TrimPrefix(cm.Text, "//tygo:emit")[1:])
 			s.WriteString("\n")
 		}
@@ -34,20 +76,25 @@ func (c *PackageGenerator) writeDirective(s *strings.Builder, cg *ast.CommentGro
 }
 
 func (g *PackageGenerator) writeCommentGroup(s *strings.Builder, cg *ast.CommentGroup, depth int) {
-	docLines := strings.Split(cg.Text(), "\n")
-
 	g.writeDirective(s, cg)
 	if len(cg.List) > 0 && cg.Text() == "" { // This is a directive comment like //go:embed
-		s.WriteByte('\n')
+		if !onlyTygoDirectives(cg) {
+			s.WriteByte('\n')
+		}
 		return
 	}
 
+	g.writeDocComment(s, cg.Text(), depth)
+}
+
+// writeDocComment writes the text as a JSDoc comment.
+func (g *PackageGenerator) writeDocComment(s *strings.Builder, text string, depth int) {
 	if depth != 0 {
 		g.writeIndent(s, depth)
 	}
 	s.WriteString("/**\n")
 
-	for _, c := range docLines {
+	for _, c := range strings.Split(text, "\n") {
 		if len(strings.TrimSpace(c)) == 0 {
 			continue
 		}
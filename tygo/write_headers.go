@@ -6,8 +6,11 @@ import (
 	"strings"
 )
 
+// The first line of every file that tygo generates.
+const codegenHeader = "// Code generated by tygo. DO NOT EDIT.\n"
+
 func (g *PackageGenerator) writeFileCodegenHeader(w *strings.Builder) {
-	w.WriteString("// Code generated by tygo. DO NOT EDIT.\n")
+	w.WriteString(codegenHeader)
 }
 
 func (g *PackageGenerator) writeFileFrontmatter(w *strings.Builder) {
@@ -4,15 +4,15 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strings"
-
-	"github.com/fatih/structtag"
 )
 
 type groupContext struct {
 	isGroupedDeclaration bool
 	doc                  *ast.CommentGroup
 	groupValue           string
+	groupExpr            ast.Expr
 	groupType            string
 	iotaValue            int
 }
@@ -43,14 +43,105 @@ func (g *PackageGenerator) emitVar(s *strings.Builder, dec *ast.GenDecl) {
 	if len(v) < 2 {
 		return
 	}
+	g.addTextReferences(v[1:len(v)-1], true)
 	s.WriteString(v[1:len(v)-1] + "\n")
 }
 
+const (
+	ignoreDirective = "tygo:ignore"
+	typeDirective   = "tygo:type"
+)
+
+// ignoredSpecs returns the specs of decl that are left out of the output, because of a
+// `//tygo:ignore` directive or because their type is left out by `exclude_types` or
+// `include_types`. A directive on the declaration itself applies to all its specs.
+func (g *PackageGenerator) ignoredSpecs(decl *ast.GenDecl) map[ast.Spec]bool {
+	ignored := make(map[ast.Spec]bool)
+	ignoreAll := hasDirective(decl.Doc, ignoreDirective)
+
+	// Constants without a type and value have the type of the constant before them (iota).
+	var constType ast.Expr
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if ignoreAll || hasDirective(spec.Doc, ignoreDirective) || g.conf.IsTypeIgnored(spec.Name.Name) ||
+				!g.isReachable(spec.Name.Name) {
+				ignored[spec] = true
+			}
+		case *ast.ValueSpec:
+			if spec.Type != nil || len(spec.Values) > 0 {
+				constType = spec.Type
+			}
+			if ignoreAll || hasDirective(spec.Doc, ignoreDirective) || g.isConstTypeIgnored(constType) {
+				ignored[spec] = true
+			}
+		}
+	}
+	return ignored
+}
+
+// enumStyle returns how the constants of the Go type are generated.
+func (g *PackageGenerator) enumStyle(typeName string) string {
+	if style := g.conf.Types[typeName].EnumStyle; style != "" {
+		return style
+	}
+	return g.conf.EnumStyle
+}
+
+// writeEmit writes Typescript code from the `emit_before` or `emit_after` option of a type.
+func (g *PackageGenerator) writeEmit(s *strings.Builder, code string) {
+	if code == "" {
+		return
+	}
+	g.addTextReferences(code, true)
+	s.WriteString(code)
+	if !strings.HasSuffix(code, "\n") {
+		s.WriteByte('\n')
+	}
+}
+
+// writeTypeDoc writes the doc comment of a type, or the `doc` option of the type if it is set.
+func (g *PackageGenerator) writeTypeDoc(s *strings.Builder, typeName string, doc *ast.CommentGroup) {
+	if !g.PreserveTypeComments() {
+		return
+	}
+	if text := g.conf.Types[typeName].Doc; text != "" {
+		if doc != nil {
+			g.writeDirective(s, doc)
+		}
+		g.writeDocComment(s, text, 0)
+		return
+	}
+	g.writeCommentGroupIfNotNil(s, doc, 0)
+}
+
+// isReachable returns false if the type is not reachable from the configured roots.
+func (g *PackageGenerator) isReachable(typeName string) bool {
+	return g.reachable == nil || g.reachable[typeName]
+}
+
+// isConstTypeIgnored returns true if constants of type t are left out of the output.
+func (g *PackageGenerator) isConstTypeIgnored(t ast.Expr) bool {
+	id, ok := t.(*ast.Ident)
+	if g.reachable != nil {
+		// Only constants of the types that are reachable from the roots are included.
+		return !ok || !g.reachable[id.Name] || g.conf.IsTypeIgnored(id.Name)
+	}
+	// Predeclared types like `string` are never left out.
+	return ok && types.Universe.Lookup(id.Name) == nil && g.conf.IsTypeIgnored(id.Name)
+}
+
 type enumGroup struct {
 	typeName   string
 	typePrefix string
+	style      string
 	constants  []*ast.ValueSpec
 	doc        *ast.CommentGroup
+
+	// For each constant its value expression, which it inherits from the constant before it
+	// if it has none, and its iota value.
+	values []ast.Expr
+	iotas  []int
 }
 
 // detectEnumGroup analyzes a const declaration group to determine if it represents
@@ -62,19 +153,16 @@ func (g *PackageGenerator) detectEnumGroup(decl *ast.GenDecl) *enumGroup {
 		return nil
 	}
 
-	// Only generate enums/unions if configured to do so
-	if g.conf.EnumStyle != "enum" && g.conf.EnumStyle != "union" {
-		return nil
-	}
-
 	var candidates []*ast.ValueSpec
 	var commonType string
 	var commonPrefix string
 
+	ignored := g.ignoredSpecs(decl)
+
 	// First pass: collect all exported constants and analyze their types
 	for _, spec := range decl.Specs {
 		vs, ok := spec.(*ast.ValueSpec)
-		if !ok {
+		if !ok || ignored[vs] {
 			continue
 		}
 
@@ -121,6 +209,17 @@ func (g *PackageGenerator) detectEnumGroup(decl *ast.GenDecl) *enumGroup {
 		commonType = commonPrefix
 	}
 
+	// Only generate enums/unions if configured to do so
+	style := g.enumStyle(commonType)
+	if style != "enum" && style != "union" {
+		return nil
+	}
+
+	// A type with a `//tygo:type` override is not an enum, its constants are written as is.
+	if _, ok := g.typeOverrides[commonType]; ok {
+		return nil
+	}
+
 	// Second pass: validate all candidates match the pattern
 	var validConstants []*ast.ValueSpec
 	for _, vs := range candidates {
@@ -152,12 +251,49 @@ func (g *PackageGenerator) detectEnumGroup(decl *ast.GenDecl) *enumGroup {
 		return nil
 	}
 
-	return &enumGroup{
+	group := &enumGroup{
 		typeName:   commonType,
 		typePrefix: commonPrefix,
+		style:      style,
 		constants:  validConstants,
 		doc:        decl.Doc,
 	}
+
+	values := make(map[*ast.ValueSpec]ast.Expr)
+	iotas := make(map[*ast.ValueSpec]int)
+	var value ast.Expr
+	for i, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if len(vs.Values) > 0 {
+			value = vs.Values[0]
+		}
+		values[vs] = value
+		iotas[vs] = i
+	}
+	for _, vs := range validConstants {
+		group.values = append(group.values, values[vs])
+		group.iotas = append(group.iotas, iotas[vs])
+	}
+
+	return group
+}
+
+// enumValue returns the value of the i-th constant of the enum group, or an empty string
+// if it has none.
+func (g *PackageGenerator) enumValue(enumGroup *enumGroup, i int) string {
+	if enumGroup.values[i] == nil {
+		return ""
+	}
+
+	tempSB := &strings.Builder{}
+	g.enterValue()
+	g.writeType(tempSB, enumGroup.values[i], nil, 0, false)
+	g.exitValue()
+	valueString := tempSB.String()
+	if isProbablyIotaType(valueString) {
+		valueString = replaceIotaValue(valueString, enumGroup.iotas[i])
+	}
+	return valueString
 }
 
 // findCommonPrefix finds the longest common prefix among constant names
@@ -195,27 +331,29 @@ func findCommonPrefix(constants []*ast.ValueSpec) string {
 	return ""
 }
 
+// checkEnumName checks the name of an enum of untyped constants, the names of declared types
+// are checked before generation.
+func (g *PackageGenerator) checkEnumName(enumGroup *enumGroup) {
+	if _, ok := g.typeNames[enumGroup.typeName]; !ok {
+		g.checkReservedName(enumGroup.constants[0], g.typeNameByRules(enumGroup.typeName), true)
+	}
+}
+
 // writeTypeScriptEnum generates a TypeScript enum declaration from an enumGroup
 func (g *PackageGenerator) writeTypeScriptEnum(s *strings.Builder, enumGroup *enumGroup) {
 	// Write enum comment if present
-	if enumGroup.doc != nil && g.PreserveTypeComments() {
-		g.writeCommentGroup(s, enumGroup.doc, 0)
-	}
+	g.writeTypeDoc(s, enumGroup.typeName, enumGroup.doc)
+	g.checkEnumName(enumGroup)
 
 	// Write enum declaration
-	s.WriteString("export enum ")
-	s.WriteString(enumGroup.typeName)
+	s.WriteString("export ")
+	s.WriteString(g.declareKeyword())
+	s.WriteString("enum ")
+	s.WriteString(g.tsTypeName(enumGroup.typeName))
 	s.WriteString(" {\n")
 
 	// Write enum members
-	memberIndex := 0
-	iotaValue := 0
-	for _, constant := range enumGroup.constants {
-		// Skip unexported constants
-		if !constant.Names[0].IsExported() {
-			continue
-		}
-
+	for i, constant := range enumGroup.constants {
 		// Write member comment if present
 		if constant.Doc != nil && g.PreserveTypeComments() {
 			g.writeCommentGroup(s, constant.Doc, 1)
@@ -228,18 +366,14 @@ func (g *PackageGenerator) writeTypeScriptEnum(s *strings.Builder, enumGroup *en
 		memberName := strings.TrimPrefix(constant.Names[0].Name, enumGroup.typePrefix)
 		s.WriteString(memberName)
 
-		// Write the value if present
-		if len(constant.Values) > 0 {
+		// A member without a value of its own is only left implicit if it continues a plain
+		// iota sequence, which is how Typescript numbers enum members.
+		isIotaContinuation := len(constant.Values) == 0 && i > 0 &&
+			enumGroup.iotas[i] == enumGroup.iotas[i-1]+1 &&
+			types.ExprString(enumGroup.values[i]) == "iota"
+		if !isIotaContinuation && enumGroup.values[i] != nil {
 			s.WriteString(" = ")
-			tempSB := &strings.Builder{}
-			g.writeType(tempSB, constant.Values[0], nil, 0, false)
-			valueString := tempSB.String()
-
-			// Handle iota values
-			if isProbablyIotaType(valueString) {
-				valueString = replaceIotaValue(valueString, iotaValue)
-			}
-			s.WriteString(valueString)
+			s.WriteString(g.enumValue(enumGroup, i))
 		}
 
 		s.WriteString(",")
@@ -250,9 +384,6 @@ func (g *PackageGenerator) writeTypeScriptEnum(s *strings.Builder, enumGroup *en
 		} else {
 			s.WriteString("\n")
 		}
-
-		memberIndex++
-		iotaValue++
 	}
 
 	s.WriteString("}\n")
@@ -261,58 +392,30 @@ func (g *PackageGenerator) writeTypeScriptEnum(s *strings.Builder, enumGroup *en
 // writeTypeScriptUnion generates a TypeScript union type declaration from an enumGroup
 func (g *PackageGenerator) writeTypeScriptUnion(s *strings.Builder, enumGroup *enumGroup) {
 	// First write each constant declaration
-	iotaValue := 0
-	var lastRawValueString string
-	var isIotaSequence bool
 	var constNames []string
 
-	for _, constant := range enumGroup.constants {
-		// Skip unexported constants
-		if !constant.Names[0].IsExported() {
-			iotaValue++
-			continue
-		}
-
-		constNames = append(constNames, constant.Names[0].Name)
+	for i, constant := range enumGroup.constants {
+		g.checkReservedName(constant.Names[0], g.constNameByRules(constant.Names[0].Name), false)
+		constNames = append(constNames, g.tsConstName(constant.Names[0].Name))
 
 		// Write constant comment if present
 		if constant.Doc != nil && g.PreserveTypeComments() {
 			g.writeCommentGroup(s, constant.Doc, 0)
 		}
 
-		// Write constant declaration without type annotation
-		s.WriteString("export const ")
-		s.WriteString(constant.Names[0].Name)
-		s.WriteString(" = ")
-
-		var valueString string
-		// Get the value if present
-		if len(constant.Values) > 0 {
-			tempSB := &strings.Builder{}
-			g.writeType(tempSB, constant.Values[0], nil, 0, false)
-			rawValueString := tempSB.String()
-
-			// Check if this starts an iota sequence
-			if isProbablyIotaType(rawValueString) {
-				isIotaSequence = true
-				valueString = replaceIotaValue(rawValueString, iotaValue)
-			} else {
-				isIotaSequence = false
-				valueString = rawValueString
-			}
-			lastRawValueString = rawValueString
-		} else if lastRawValueString != "" {
-			// If no explicit value but we have a pattern, continue based on sequence type
-			if isIotaSequence {
-				// Continue the iota sequence
-				valueString = replaceIotaValue(lastRawValueString, iotaValue)
-			} else {
-				// For non-iota patterns, reuse the last value
-				valueString = lastRawValueString
-			}
+		// Write constant declaration without type annotation, a declaration file has the
+		// value as its type instead.
+		s.WriteString("export ")
+		s.WriteString(g.declareKeyword())
+		s.WriteString("const ")
+		s.WriteString(constNames[i])
+		if g.isDeclarationFile() {
+			s.WriteString(": ")
+			s.WriteString(g.declaredValueType(constant.Names[0], enumGroup.values[i], enumGroup.iotas[i]))
+		} else {
+			s.WriteString(" = ")
+			s.WriteString(g.enumValue(enumGroup, i))
 		}
-
-		s.WriteString(valueString)
 		s.WriteString(";")
 
 		// Write line comment if present
@@ -321,18 +424,15 @@ func (g *PackageGenerator) writeTypeScriptUnion(s *strings.Builder, enumGroup *e
 		} else {
 			s.WriteString("\n")
 		}
-
-		iotaValue++
 	}
 
 	// Write union type comment if present
-	if enumGroup.doc != nil && g.PreserveTypeComments() {
-		g.writeCommentGroup(s, enumGroup.doc, 0)
-	}
+	g.writeTypeDoc(s, enumGroup.typeName, enumGroup.doc)
+	g.checkEnumName(enumGroup)
 
 	// Write union type declaration using typeof references
 	s.WriteString("export type ")
-	s.WriteString(enumGroup.typeName)
+	s.WriteString(g.tsTypeName(enumGroup.typeName))
 	s.WriteString(" = ")
 
 	// Write the union of typeof references
@@ -355,8 +455,13 @@ func (g *PackageGenerator) writeGroupDecl(s *strings.Builder, decl *ast.GenDecl)
 	// )
 	isGroupedDeclaration := len(decl.Specs) > 1
 
+	ignored := g.ignoredSpecs(decl)
+
 	// Check if decl is exported, if not, we exit early so we don't write its comment.
 	if !isGroupedDeclaration {
+		if ignored[decl.Specs[0]] {
+			return
+		}
 		if ts, ok := decl.Specs[0].(*ast.TypeSpec); ok && !ts.Name.IsExported() {
 			return
 		}
@@ -368,16 +473,22 @@ func (g *PackageGenerator) writeGroupDecl(s *strings.Builder, decl *ast.GenDecl)
 	// Check if this is an enum group and handle it specially
 	enumGroup := g.detectEnumGroup(decl)
 	if enumGroup != nil {
-		switch g.conf.EnumStyle {
+		tc := g.conf.Types[enumGroup.typeName]
+		g.writeEmit(s, tc.EmitBefore)
+		switch enumGroup.style {
 		case "enum":
 			g.writeTypeScriptEnum(s, enumGroup)
 		case "union":
 			g.writeTypeScriptUnion(s, enumGroup)
 		}
+		g.writeEmit(s, tc.EmitAfter)
 	}
 
 	if !isGroupedDeclaration && g.PreserveTypeComments() {
-		g.writeCommentGroupIfNotNil(s, decl.Doc, 0)
+		// The comment of a type is written along with it, unless it is replaced by an enum.
+		if ts, ok := decl.Specs[0].(*ast.TypeSpec); !ok || g.generatedEnums[ts.Name.Name] {
+			g.writeCommentGroupIfNotNil(s, decl.Doc, 0)
+		}
 	}
 
 	// We need a bit of state to handle syntax like
@@ -410,6 +521,16 @@ func (g *PackageGenerator) writeGroupDecl(s *strings.Builder, decl *ast.GenDecl)
 		if vs, ok := spec.(*ast.ValueSpec); ok && enumConstants[vs] {
 			continue
 		}
+		if ignored[spec] {
+			// Ignored constants are still processed, as the constants after them can
+			// inherit their type and value.
+			if vs, ok := spec.(*ast.ValueSpec); ok {
+				g.suppressDiagnostics++
+				g.writeValueSpec(new(strings.Builder), vs, group)
+				g.suppressDiagnostics--
+			}
+			continue
+		}
 		g.writeSpec(s, spec, group)
 	}
 }
@@ -442,44 +563,58 @@ func (g *PackageGenerator) writeTypeSpec(
 		return
 	}
 
-	if ts.Doc != nil &&
-		g.PreserveTypeComments() { // The spec has its own comment, which overrules the grouped comment.
-		g.writeCommentGroup(s, ts.Doc, 0)
-	} else if group.isGroupedDeclaration && g.PreserveTypeComments() {
-		g.writeCommentGroupIfNotNil(s, group.doc, 0)
+	g.pushPath(ts.Name.Name)
+	defer g.popPath()
+
+	tc := g.conf.Types[ts.Name.Name]
+	g.writeEmit(s, tc.EmitBefore)
+	defer g.writeEmit(s, tc.EmitAfter)
+
+	// The spec has its own comment, which overrules the grouped comment.
+	doc := ts.Doc
+	if doc == nil {
+		doc = group.doc
+	}
+	g.writeTypeDoc(s, ts.Name.Name, doc)
+
+	name := g.tsTypeName(ts.Name.Name)
+
+	if tsType, ok := g.typeOverrides[ts.Name.Name]; ok {
+		s.WriteString("export type ")
+		s.WriteString(name)
+		if ts.TypeParams != nil {
+			g.writeTypeParamsFields(s, ts.TypeParams.List)
+		}
+		s.WriteString(" = ")
+		g.addTextReferences(tsType, false)
+		s.WriteString(tsType)
+		s.WriteString(";")
+		g.writeTypeSpecComment(s, ts)
+		return
 	}
 
 	st, isStruct := ts.Type.(*ast.StructType)
 	if isStruct {
 		s.WriteString("export interface ")
-		s.WriteString(ts.Name.Name)
-		if g.conf.Extends != "" {
-			s.WriteString(" extends ")
-			s.WriteString(g.conf.Extends)
-		}
+		s.WriteString(name)
 
 		if ts.TypeParams != nil {
 			g.writeTypeParamsFields(s, ts.TypeParams.List)
 		}
 
-		g.writeTypeInheritanceSpec(s, st.Fields.List)
+		extends := g.conf.Extends
+		if tc.Extends != "" {
+			extends = tc.Extends
+		}
+		g.writeTypeInheritanceSpec(s, extends, st.Fields.List)
 		s.WriteString(" {\n")
 		g.writeStructFields(s, st.Fields.List, 0)
 		s.WriteString("}")
 	}
 
-	id, isIdent := ts.Type.(*ast.Ident)
-	if isIdent {
+	if !isStruct {
 		s.WriteString("export type ")
-		s.WriteString(ts.Name.Name)
-		s.WriteString(" = ")
-		s.WriteString(getIdent(id.Name))
-		s.WriteString(";")
-	}
-
-	if !isStruct && !isIdent {
-		s.WriteString("export type ")
-		s.WriteString(ts.Name.Name)
+		s.WriteString(name)
 
 		if ts.TypeParams != nil {
 			g.writeTypeParamsFields(s, ts.TypeParams.List)
@@ -491,6 +626,11 @@ func (g *PackageGenerator) writeTypeSpec(
 
 	}
 
+	g.writeTypeSpecComment(s, ts)
+}
+
+// writeTypeSpecComment ends the declaration of the type with its trailing comment, if any.
+func (g *PackageGenerator) writeTypeSpecComment(s *strings.Builder, ts *ast.TypeSpec) {
 	if ts.Comment != nil && g.PreserveTypeComments() {
 		g.writeSingleLineComment(s, ts.Comment)
 	} else {
@@ -502,21 +642,25 @@ func (g *PackageGenerator) writeTypeSpec(
 // `type X struct {  }`
 // `type Y struct { X `tstype:",extends"` }`
 // `export interface Y extends X { }`
-func (g *PackageGenerator) writeTypeInheritanceSpec(s *strings.Builder, fields []*ast.Field) {
+// The interface also extends the configured `extends` interface, if any.
+func (g *PackageGenerator) writeTypeInheritanceSpec(s *strings.Builder, extends string, fields []*ast.Field) {
 	inheritances := make([]string, 0)
+	if extends != "" {
+		g.addTextReferences(extends, false)
+		inheritances = append(inheritances, extends)
+	}
 	for _, f := range fields {
+		if hasDirective(f.Doc, ignoreDirective) || g.isFieldDropped(f) {
+			continue
+		}
 		if f.Type != nil && f.Tag != nil {
-			tags, err := structtag.Parse(f.Tag.Value[1 : len(f.Tag.Value)-1])
-			if err != nil {
-				panic(err)
-			}
-
-			tstypeTag, err := tags.Get("tstype")
-			if err != nil || !tstypeTag.HasOption("extends") {
+			// An invalid tag is reported when the fields are written.
+			_, tstype, err := parseFieldTag(f)
+			if err != nil || tstype == nil || !tstype.HasOption("extends") {
 				continue
 			}
 
-			longType, valid := getInheritedType(f.Type, tstypeTag)
+			longType, valid := g.getInheritedType(f.Type, tstype)
 			if valid {
 				mappedTsType, ok := g.conf.TypeMappings[longType]
 				if ok {
@@ -550,6 +694,7 @@ func (g *PackageGenerator) writeValueSpec(
 		if !name.IsExported() {
 			continue
 		}
+		g.pushPath(name.Name)
 
 		if vs.Doc != nil &&
 			g.PreserveTypeComments() { // The spec has its own comment, which overrules the grouped comment.
@@ -563,37 +708,52 @@ func (g *PackageGenerator) writeValueSpec(
 			group.groupType = ""
 		}
 
-		s.WriteString("export const ")
-		s.WriteString(name.Name)
+		g.checkReservedName(name, g.constNameByRules(name.Name), false)
+		typeString := ""
 		if vs.Type != nil {
-			s.WriteString(": ")
-
 			tempSB := &strings.Builder{}
 			g.writeType(tempSB, vs.Type, nil, 0, true)
-			typeString := tempSB.String()
-
-			s.WriteString(typeString)
+			typeString = tempSB.String()
 			group.groupType = typeString
 		} else if group.groupType != "" && !hasExplicitValue {
-			s.WriteString(": ")
-			s.WriteString(group.groupType)
+			typeString = group.groupType
 		}
 
-		s.WriteString(" = ")
-
 		if hasExplicitValue {
 			val := vs.Values[i]
 			tempSB := &strings.Builder{}
 			// log.Println("const:", name.Name, reflect.TypeOf(val), val)
+			g.enterValue()
 			g.writeType(tempSB, val, nil, 0, false)
+			g.exitValue()
 			group.groupValue = tempSB.String()
+			group.groupExpr = val
 		}
 
 		valueString := group.groupValue
 		if isProbablyIotaType(valueString) {
 			valueString = replaceIotaValue(valueString, group.iotaValue)
 		}
-		s.WriteString(valueString)
+
+		s.WriteString("export ")
+		s.WriteString(g.declareKeyword())
+		s.WriteString("const ")
+		s.WriteString(g.tsConstName(name.Name))
+		if g.isDeclarationFile() {
+			// A declaration file has no values, so the literal value becomes the type.
+			if typeString == "" {
+				typeString = g.declaredValueType(name, group.groupExpr, group.iotaValue)
+			}
+			s.WriteString(": ")
+			s.WriteString(typeString)
+		} else {
+			if typeString != "" {
+				s.WriteString(": ")
+				s.WriteString(typeString)
+			}
+			s.WriteString(" = ")
+			s.WriteString(valueString)
+		}
 
 		s.WriteByte(';')
 
@@ -602,11 +762,27 @@ func (g *PackageGenerator) writeValueSpec(
 		} else {
 			s.WriteByte('\n')
 		}
+		g.popPath()
 
 	}
 }
 
-func getInheritedType(f ast.Expr, tag *structtag.Tag) (name string, valid bool) {
+// typeArguments returns the type arguments of a generic type in the output, e.g.
+// `<RecordDto, string[]>`.
+func (g *PackageGenerator) typeArguments(args []ast.Expr) string {
+	s := new(strings.Builder)
+	s.WriteByte('<')
+	for i, arg := range args {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		g.writeType(s, arg, nil, 0, false)
+	}
+	s.WriteByte('>')
+	return s.String()
+}
+
+func (g *PackageGenerator) getInheritedType(f ast.Expr, tag *tstypeTag) (name string, valid bool) {
 	switch ft := f.(type) {
 	case *ast.Ident:
 		if ft.Obj != nil && ft.Obj.Decl != nil {
@@ -614,33 +790,34 @@ func getInheritedType(f ast.Expr, tag *structtag.Tag) (name string, valid bool)
 			if ok {
 				_, isStruct := dcl.Type.(*ast.StructType)
 				valid = isStruct && dcl.Name.IsExported()
-				name = dcl.Name.Name
+				name = g.tsTypeName(dcl.Name.Name)
 			}
 		} else {
 			// Types defined in the Go file after the parsed file in the same package
 			valid = token.IsExported(ft.Name)
-			name = ft.Name
+			name = g.tsTypeName(ft.Name)
+		}
+		if valid {
+			g.addReference(name)
 		}
 	case *ast.IndexExpr:
-		name, valid = getInheritedType(ft.X, tag)
+		name, valid = g.getInheritedType(ft.X, tag)
 		if valid {
-			generic := getIdent(ft.Index.(*ast.Ident).Name)
-			name += fmt.Sprintf("<%s>", generic)
+			name += g.typeArguments([]ast.Expr{ft.Index})
 		}
 	case *ast.IndexListExpr:
-		name, valid = getInheritedType(ft.X, tag)
+		name, valid = g.getInheritedType(ft.X, tag)
 		if valid {
-			generic := ""
-			for _, index := range ft.Indices {
-				generic += fmt.Sprintf("%s, ", getIdent(index.(*ast.Ident).Name))
-			}
-			name += fmt.Sprintf("<%s>", generic[:len(generic)-2])
+			name += g.typeArguments(ft.Indices)
 		}
 	case *ast.SelectorExpr:
 		valid = ft.Sel.IsExported()
 		name = fmt.Sprintf("%s.%s", ft.X, ft.Sel)
+		if bundled, ok := g.bundledTypeName(ft); ok {
+			name = bundled
+		}
 	case *ast.StarExpr:
-		name, valid = getInheritedType(ft.X, tag)
+		name, valid = g.getInheritedType(ft.X, tag)
 		if valid {
 			// If the type is not required, mark as optional inheritance
 			if !tag.HasOption("required") {